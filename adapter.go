@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// testAdapter runs the tests through a wrapper, such as gotestsum or
+// ginkgo, instead of go test -json, and parses what it reports.
+type testAdapter struct {
+	// Command is the wrapper and its arguments. {args} is replaced by
+	// the go test arguments selene passes, the overlay and the package
+	// among them, and {report} by the path of a file for the report of
+	// the wrapper, read instead of its output.
+	Command []string `json:"command"`
+	// Format is how the results are reported: gotestsum, as go test
+	// -json, ginkgo, as its JSON report, or tap.
+	Format string `json:"format"`
+}
+
+// adapterFormats parse the results reported by a test wrapper.
+var adapterFormats = map[string]func([]byte) ([]TestEvent, error){
+	"gotestsum": parseGoTestOutput,
+	"ginkgo":    parseGinkgoReport,
+	"tap":       parseTAP,
+}
+
+// check validates the adapter and returns it, or nil if none is set.
+func (a testAdapter) check() (*testAdapter, error) {
+	if len(a.Command) == 0 {
+		if a.Format != "" {
+			return nil, &ConfigError{Err: fmt.Errorf("test.format set without test.command")}
+		}
+		return nil, nil
+	}
+
+	if _, ok := adapterFormats[a.Format]; !ok {
+		return nil, &ConfigError{Err: fmt.Errorf("unknown test.format %q, expected gotestsum, ginkgo or tap", a.Format)}
+	}
+	if !slices.Contains(a.Command, "{args}") {
+		return nil, &ConfigError{Err: fmt.Errorf("test.command must pass the go test arguments with {args}")}
+	}
+	if a.Format == "ginkgo" && !slices.ContainsFunc(a.Command, hasReport) {
+		return nil, &ConfigError{Err: fmt.Errorf("test.command must write the ginkgo report to {report}, as in --json-report={report}")}
+	}
+
+	return &a, nil
+}
+
+// hasReport reports whether a command argument names the report file.
+func hasReport(arg string) bool {
+	return strings.Contains(arg, "{report}")
+}
+
+// expand returns the command with its placeholders replaced.
+func (a *testAdapter) expand(args []string, report string) []string {
+	var command []string
+	for _, arg := range a.Command {
+		if arg == "{args}" {
+			command = append(command, args...)
+			continue
+		}
+		command = append(command, strings.ReplaceAll(arg, "{report}", report))
+	}
+	return command
+}
+
+// run runs the wrapper in dir and returns its output and the results it
+// reported. A wrapper failing without reporting any result couldn't run
+// the tests, which is reported as a failed build of dir.
+func (a *testAdapter) run(tc toolchain, dir string, args []string, report string) ([]byte, []TestEvent, testUsage, error) {
+	os.Remove(report)
+
+	command := a.expand(args, report)
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = tc.environ(dir)
+
+	start := time.Now()
+	out, runErr := cmd.CombinedOutput()
+	if runErr != nil {
+		log.Println(runErr)
+	}
+	u := measure(cmd.ProcessState, time.Since(start))
+
+	results := out
+	if slices.ContainsFunc(a.Command, hasReport) {
+		var err error
+		results, err = os.ReadFile(report)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return out, nil, u, fmt.Errorf("failed to read test report: %s", err)
+		}
+	}
+
+	tests, err := adapterFormats[a.Format](results)
+	if err != nil {
+		return out, nil, u, err
+	}
+
+	if runErr != nil && len(tests) == 0 {
+		tests = []TestEvent{{Action: "fail", FailedBuild: dir}}
+	}
+
+	return out, tests, u, nil
+}
+
+// ginkgoSuite is the part of a suite in a ginkgo JSON report selene uses.
+type ginkgoSuite struct {
+	SuitePath                 string
+	SuiteSucceeded            bool
+	SuiteHasProgrammaticFocus bool
+	SpecReports               []struct {
+		ContainerHierarchyTexts []string
+		LeafNodeText            string
+		State                   string
+	}
+}
+
+// parseGinkgoReport turns the failed specs of a ginkgo JSON report into
+// test failures, and failed suites without any into package failures.
+func parseGinkgoReport(data []byte) ([]TestEvent, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var suites []ginkgoSuite
+	err := json.Unmarshal(data, &suites)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ginkgo report: %s", err)
+	}
+
+	var tests []TestEvent
+	for _, suite := range suites {
+		failed := false
+		for _, spec := range suite.SpecReports {
+			name := strings.Join(append(spec.ContainerHierarchyTexts, spec.LeafNodeText), " ")
+			switch spec.State {
+			case "failed", "panicked", "interrupted", "aborted", "timedout":
+				failed = true
+				// ginkgo recovers the panics and timeouts of specs,
+				// which go test would print
+				switch spec.State {
+				case "panicked":
+					tests = append(tests, TestEvent{Action: "output", Package: suite.SuitePath, Test: name, Output: "panic: spec panicked\n"})
+				case "timedout":
+					tests = append(tests, TestEvent{Action: "output", Package: suite.SuitePath, Test: name, Output: "panic: test timed out\n"})
+				}
+				tests = append(tests, TestEvent{Action: "fail", Package: suite.SuitePath, Test: name})
+			case "passed":
+				tests = append(tests, TestEvent{Action: "pass", Package: suite.SuitePath, Test: name})
+			default:
+				tests = append(tests, TestEvent{Action: "skip", Package: suite.SuitePath, Test: name})
+			}
+		}
+
+		if !suite.SuiteSucceeded && !failed {
+			tests = append(tests, TestEvent{Action: "fail", Package: suite.SuitePath})
+		}
+	}
+
+	return tests, nil
+}
+
+// focusedSuites returns the suites of a ginkgo JSON report with specs
+// focused in the code, as with FIt, so their other specs never run.
+func focusedSuites(report string) []string {
+	data, err := os.ReadFile(report)
+	if err != nil {
+		return nil
+	}
+
+	var suites []ginkgoSuite
+	if json.Unmarshal(data, &suites) != nil {
+		return nil
+	}
+
+	var focused []string
+	for _, suite := range suites {
+		if suite.SuiteHasProgrammaticFocus {
+			focused = append(focused, displayPath(suite.SuitePath))
+		}
+	}
+	return focused
+}
+
+// parseTAP turns the test points of TAP output into test results. Points
+// marked TODO don't count, and bailing out fails the package.
+func parseTAP(data []byte) ([]TestEvent, error) {
+	var tests []TestEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "Bail out!") {
+			tests = append(tests, TestEvent{Action: "fail", Output: line})
+			continue
+		}
+
+		action := "pass"
+		rest, ok := strings.CutPrefix(line, "ok")
+		if !ok {
+			rest, ok = strings.CutPrefix(line, "not ok")
+			action = "fail"
+		}
+		if !ok || rest != "" && rest[0] != ' ' {
+			continue
+		}
+
+		name, directive, _ := strings.Cut(rest, "#")
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(directive)), "TODO") {
+			continue
+		}
+
+		// the number and the dash before the description are optional
+		name = strings.TrimLeft(name, " 0123456789")
+		name = strings.TrimSpace(strings.TrimPrefix(name, "-"))
+		tests = append(tests, TestEvent{Action: action, Test: name})
+	}
+
+	return tests, scanner.Err()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// assertions are the testing.T methods that make a test fail.
+var assertions = map[string]bool{
+	"Error":   true,
+	"Errorf":  true,
+	"Fatal":   true,
+	"Fatalf":  true,
+	"Fail":    true,
+	"FailNow": true,
+}
+
+// assertionPackages are the usual assertion libraries, as imported by
+// name: any call to them counts as an assertion.
+var assertionPackages = map[string]bool{
+	"assert":  true,
+	"require": true,
+}
+
+// assertionFreeTests returns the tests of the package in dir that can't
+// fail: they never call the assertion methods of testing.T, an assertion
+// library or a helper taking their testing.T. Such tests can't kill any
+// mutant, however much code they run.
+func assertionFreeTests(ctx *build.Context, dir string) ([]string, error) {
+	bpkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	fset := token.NewFileSet()
+	for _, name := range append(bpkg.TestGoFiles, bpkg.XTestGoFiles...) {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil || !strings.HasPrefix(fn.Name.Name, "Test") {
+				continue
+			}
+
+			if isTest(fn.Type) && !asserts(fn) {
+				found = append(found, fn.Name.Name)
+			}
+		}
+	}
+
+	sort.Strings(found)
+	return found, nil
+}
+
+// isTest reports whether the function takes a single *testing.T.
+func isTest(ft *ast.FuncType) bool {
+	if ft.Params.NumFields() != 1 {
+		return false
+	}
+	return isTestingT(ft.Params.List[0].Type)
+}
+
+func isTestingT(expr ast.Expr) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "testing" && sel.Sel.Name == "T"
+}
+
+// asserts reports whether fn, or any subtest within it, may fail.
+func asserts(fn *ast.FuncDecl) bool {
+	// names of the testing.T of the test and its subtests
+	ts := map[string]bool{}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		var ft *ast.FuncType
+		switch x := n.(type) {
+		case *ast.FuncDecl:
+			ft = x.Type
+		case *ast.FuncLit:
+			ft = x.Type
+		default:
+			return true
+		}
+
+		for _, field := range ft.Params.List {
+			if isTestingT(field.Type) {
+				for _, name := range field.Names {
+					ts[name.Name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found {
+			return !found
+		}
+
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				if ts[x.Name] && assertions[sel.Sel.Name] || assertionPackages[x.Name] {
+					found = true
+					return false
+				}
+			}
+		}
+
+		// helpers taking the testing.T may fail the test themselves
+		for _, arg := range call.Args {
+			if id, ok := arg.(*ast.Ident); ok && ts[id.Name] {
+				found = true
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return found
+}
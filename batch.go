@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// batches groups the indexes of mutants tested together with --batch: up
+// to size mutants of the same file, each in a different function, none of
+// which calls another, directly or not, as in graph. Otherwise a mutant of
+// a caller could keep a callee from running, and hide its mutants. Calls
+// through interfaces aren't in graph, which leaves room for a few false
+// survivors. Mutants outside functions of the graph, and all of them when
+// size is below 2, are groups of their own.
+func batches(mutants []mutant, size int, graph map[*types.Func]*callNode) [][]int {
+	var groups [][]int
+	open := map[string][]int{}           // groups of each file with room left
+	funcs := map[int][]*types.Func{}     // functions mutated in each group
+	reach := map[*types.Func]funcReach{} // functions each one calls
+	for i, mt := range mutants {
+		var fn *types.Func
+		if size > 1 {
+			fn = enclosingFunc(graph, mt)
+		}
+		if fn == nil {
+			groups = append(groups, []int{i})
+			continue
+		}
+		if _, ok := reach[fn]; !ok {
+			reach[fn] = reachable(graph, fn)
+		}
+
+		g := slices.IndexFunc(open[mt.File], func(g int) bool {
+			return !slices.ContainsFunc(funcs[g], func(other *types.Func) bool {
+				return other == fn || reach[fn][other] || reach[other][fn]
+			})
+		})
+		if g < 0 {
+			groups = append(groups, nil)
+			open[mt.File] = append(open[mt.File], len(groups)-1)
+			g = len(open[mt.File]) - 1
+		}
+
+		group := open[mt.File][g]
+		groups[group] = append(groups[group], i)
+		funcs[group] = append(funcs[group], fn)
+		if len(groups[group]) == size {
+			open[mt.File] = slices.Delete(open[mt.File], g, g+1)
+		}
+	}
+	return groups
+}
+
+// funcReach is the set of functions a function calls, directly or not.
+type funcReach map[*types.Func]bool
+
+// reachable returns the functions of graph fn calls, directly or not.
+func reachable(graph map[*types.Func]*callNode, fn *types.Func) funcReach {
+	reach := funcReach{}
+	frontier := []*types.Func{fn}
+	for len(frontier) > 0 {
+		var next []*types.Func
+		for _, f := range frontier {
+			for _, callee := range graph[f].callees {
+				if !reach[callee] {
+					reach[callee] = true
+					next = append(next, callee)
+				}
+			}
+		}
+		frontier = next
+	}
+	return reach
+}
+
+// enclosingFunc returns the function of graph the mutant is in, or nil.
+func enclosingFunc(graph map[*types.Func]*callNode, mt mutant) *types.Func {
+	for fn, node := range graph {
+		if node.filename == mt.File && node.start <= mt.Pos.Line && mt.Pos.Line <= node.end {
+			return fn
+		}
+	}
+	return nil
+}
+
+// span is a function declaration of a file, by the range of its offsets.
+type span struct {
+	start, end int
+	name       string // as Func or Type.Method
+}
+
+// funcSpans returns the functions declared in filename, or none if it
+// can't be parsed.
+func funcSpans(filename string) []span {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var spans []span
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			spans = append(spans, span{fset.Position(fn.Pos()).Offset, fset.Position(fn.End()).Offset, funcName(fn)})
+		}
+	}
+	return spans
+}
+
+// enclosingFuncs returns the name of the function each mutant is in, or
+// an empty string for mutants outside functions.
+func enclosingFuncs(mutants []mutant) []string {
+	names := make([]string, len(mutants))
+	spans := map[string][]span{}
+	for i, mt := range mutants {
+		if _, ok := spans[mt.File]; !ok {
+			spans[mt.File] = funcSpans(mt.File)
+		}
+		for _, s := range spans[mt.File] {
+			if s.start <= mt.Pos.Offset && mt.Pos.Offset < s.end {
+				names[i] = s.name
+			}
+		}
+	}
+	return names
+}
+
+// runBatch tests the mutants of a batch in a single go test run, with its
+// files in dir. As tests failing can't be told apart by mutant, it returns
+// results only if all of them survived, and nil for them to be tested one
+// by one otherwise, as when they can't be applied together. Survivors get
+// their own files in dirs, so each one can be reproduced on its own.
+func (r *runner) runBatch(pkgDir string, mts []mutant, dir string, dirs []string, testFlags []string) ([]report.Mutant, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced, _, err := writeMutants(r.opts.toolchain.buildContext(), mts, dir)
+	if err != nil {
+		log.Printf("batch of %d mutants: %s, testing them one by one", len(mts), err)
+		return nil, nil
+	}
+
+	overlay := filepath.Join(dir, "overlay.json")
+	merged, err := mergeOverlays(r.userOverlay, replaced)
+	if err != nil {
+		return nil, err
+	}
+	_, err = writeOverlay(overlay, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("running go test for a batch of %d mutants in %s", len(mts), dir)
+
+	// a single failure is enough to test them one by one
+	logFile := filepath.Join(dir, "gotest.log.gz")
+	tests, err := r.opts.toolchain.runGoTest(pkgDir, overlay, logFile, append(slices.Clip(testFlags), "-failfast"))
+	if err != nil {
+		return nil, fmt.Errorf("error running go test: %s", err)
+	}
+
+	if status, _ := verdict(tests); status != report.Survived {
+		log.Printf("batch of %d mutants in %s didn't survive, testing them one by one", len(mts), dir)
+		return nil, nil
+	}
+
+	results := make([]report.Mutant, len(mts))
+	for i, mt := range mts {
+		results[i], err = r.prepareMutant(mt, dirs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].Status = report.Survived
+		results[i].Log = logFile
+		results[i].Repro = r.reproCommand(mt.ID)
+		results[i].GoTest = r.opts.toolchain.testCommand(pkgDir, results[i].Overlay, testFlags)
+		r.settle(mt, &results[i], tests)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// bundle packages a finished run for offline review: the JSON and HTML
+// reports, a diff of every surviving mutant and the go test logs, in a
+// single archive CI can upload. bundle open unpacks and serves one.
+func bundle(args []string) error {
+	if len(args) > 0 && args[0] == "open" {
+		return openBundle(args[1:])
+	}
+
+	fs := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	output := fs.String("o", "results.tar.gz", "archive `file` to write")
+	err := fs.Parse(args)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if fs.NArg() != 1 {
+		return &ConfigError{Err: fmt.Errorf("usage: selene bundle [-o results.tar.gz] <report.json>\n       selene bundle open [-addr localhost:8000] <results.tar.gz>")}
+	}
+
+	doc, err := report.ReadDocument(fs.Arg(0))
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	// logs and diffs are stored by mutant number, and the reports point
+	// at them relative to the root of the archive
+	n := 0
+	for i, result := range doc.Results {
+		for j, m := range result.Mutants {
+			n++
+			dir := path.Join("mutants", strconv.Itoa(n))
+
+			if log, err := os.ReadFile(m.Log); err == nil {
+				err = addFile(tw, path.Join(dir, "gotest.log.gz"), log)
+				if err != nil {
+					return err
+				}
+				doc.Results[i].Mutants[j].Log = path.Join(dir, "gotest.log.gz")
+			} else {
+				fmt.Fprintf(os.Stderr, "selene: %s: log not found, was the mutation directory removed?\n", m.ID)
+			}
+
+			if m.Status != report.Survived {
+				continue
+			}
+
+			diff, err := mutantDiff(m)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "selene: %s: no diff: %s\n", m.ID, err)
+				continue
+			}
+			err = addFile(tw, path.Join(dir, "mutant.diff"), diff)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	jsonDoc, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = addFile(tw, "report.json", jsonDoc)
+	if err != nil {
+		return err
+	}
+
+	var html bytes.Buffer
+	err = report.RenderHTML(&html, doc)
+	if err != nil {
+		return err
+	}
+	err = addFile(tw, "index.html", html.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d mutants bundled in %s\n", n, *output)
+	return f.Close()
+}
+
+func addFile(tw *tar.Writer, name string, content []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(content)
+	return err
+}
+
+// mutantDiff returns the diff between the source file of a mutant and
+// its mutated copy, found through the overlay of the mutant.
+func mutantDiff(m report.Mutant) ([]byte, error) {
+	overlay, err := readOverlay(m.Overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	mutated, ok := overlay[m.File]
+	if !ok {
+		return nil, fmt.Errorf("%s not in overlay", m.File)
+	}
+
+	before, err := os.ReadFile(m.File)
+	if err != nil {
+		return nil, err
+	}
+	after, err := os.ReadFile(mutated)
+	if err != nil {
+		return nil, err
+	}
+
+	return unifiedDiff(displayPath(m.File), before, after), nil
+}
+
+// unifiedDiff returns the diff of a and b as a single hunk: mutations
+// change one place in a file, so the lines in common at both ends are all
+// there is to skip. A last line without a newline is marked as such, so
+// patch applies the diff as it is.
+func unifiedDiff(name string, a, b []byte) []byte {
+	const context = 3
+
+	al := splitLines(a)
+	bl := splitLines(b)
+	prefix, suffix := commonEnds(al, bl)
+
+	start := max(prefix-context, 0)
+	aEnd := min(len(al)-suffix+context, len(al))
+	bEnd := min(len(bl)-suffix+context, len(bl))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- a/%s\n+++ b/%s\n", name, name)
+	fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(start, aEnd-start), hunkRange(start, bEnd-start))
+	writeLines := func(prefix string, lines []string) {
+		for _, line := range lines {
+			buf.WriteString(prefix + line)
+			if !strings.HasSuffix(line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	writeLines(" ", al[start:prefix])
+	writeLines("-", al[prefix:len(al)-suffix])
+	writeLines("+", bl[prefix:len(bl)-suffix])
+	writeLines(" ", al[len(al)-suffix:aEnd])
+	return buf.Bytes()
+}
+
+// hunkRange returns the range of a hunk of n lines after the first start
+// lines of a file. Empty ranges are given by the line before them.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines returns the lines of b with their line endings, leaving out
+// the empty one after a final newline.
+func splitLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// commonEnds returns how many lines a and b have in common at their start
+// and, after those, at their end.
+func commonEnds(a, b []string) (prefix, suffix int) {
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return prefix, suffix
+}
+
+// maxChangedLines is the most lines a mutation is expected to change, as
+// removing a whole statement or block. Longer changes aren't described.
+const maxChangedLines = 3
+
+// mutatedLines returns the lines a mutation changed in a, before and
+// after it, each one trimmed and joined by spaces. Both are empty if it
+// changed more than maxChangedLines lines.
+func mutatedLines(a, b []byte) (before, after string) {
+	al := strings.Split(string(a), "\n")
+	bl := strings.Split(string(b), "\n")
+	prefix, suffix := commonEnds(al, bl)
+
+	removed := al[prefix : len(al)-suffix]
+	added := bl[prefix : len(bl)-suffix]
+	if len(removed) > maxChangedLines || len(added) > maxChangedLines {
+		return "", ""
+	}
+	return joinTrimmed(removed), joinTrimmed(added)
+}
+
+func joinTrimmed(lines []string) string {
+	var trimmed []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			trimmed = append(trimmed, line)
+		}
+	}
+	return strings.Join(trimmed, " ")
+}
+
+// openBundle unpacks a bundle to a temporary directory and serves it
+// until interrupted.
+func openBundle(args []string) error {
+	fs := flag.NewFlagSet("bundle open", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8000", "`address` to serve the bundle on")
+	err := fs.Parse(args)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if fs.NArg() != 1 {
+		return &ConfigError{Err: fmt.Errorf("usage: selene bundle open [-addr localhost:8000] <results.tar.gz>")}
+	}
+
+	dir, err := os.MkdirTemp("", "bundle")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	err = unpack(fs.Arg(0), dir)
+	if err != nil {
+		return &ConfigError{Err: fmt.Errorf("invalid bundle %s: %s", fs.Arg(0), err)}
+	}
+
+	return serve(*addr, dir, http.FileServer(http.Dir(dir)))
+}
+
+// unpack extracts a bundle into dir, rejecting entries outside of it.
+func unpack(filename, dir string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// entries are separated by slashes, a backslash would separate
+		// them on Windows only
+		name := filepath.FromSlash(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || strings.Contains(hdr.Name, `\`) || !filepath.IsLocal(name) {
+			return fmt.Errorf("unexpected entry %s", hdr.Name)
+		}
+
+		target := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(target), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// serve serves handler on addr until interrupted, printing what and where.
+// On interrupt the server is shut down and serve returns, so the deferred
+// cleanups of its callers run.
+func serve(addr, what string, handler http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Handler: handler}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	fmt.Printf("serving %s on http://%s/, press Ctrl+C to stop\n", what, ln.Addr())
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
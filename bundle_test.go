@@ -0,0 +1,154 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "changed line in context",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "first line",
+			a:    "1\n2\n",
+			b:    "one\n2\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n",
+		},
+		{
+			name: "removed line",
+			a:    "1\n2\n3\n",
+			b:    "1\n3\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,2 @@\n 1\n-2\n 3\n",
+		},
+		{
+			name: "last line",
+			a:    "1\n2\n3\n",
+			b:    "1\n2\nthree\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,3 @@\n 1\n 2\n-3\n+three\n",
+		},
+		{
+			name: "no newline at end of file",
+			a:    "1\n2",
+			b:    "one\n2",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n\\ No newline at end of file\n",
+		},
+		{
+			name: "newline added at end of file",
+			a:    "1\n2",
+			b:    "1\n2\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n 1\n-2\n\\ No newline at end of file\n+2\n",
+		},
+		{
+			name: "all lines removed",
+			a:    "1\n",
+			b:    "",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,1 +0,0 @@\n-1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(unifiedDiff("f.go", []byte(tt.a), []byte(tt.b))); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutatedLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		a, b          string
+		before, after string
+	}{
+		{
+			name:   "changed line",
+			a:      "func f() {\n\tif a > b {\n\t}\n}\n",
+			b:      "func f() {\n\tif a >= b {\n\t}\n}\n",
+			before: "if a > b {",
+			after:  "if a >= b {",
+		},
+		{
+			name:   "removed statement",
+			a:      "func f() {\n\tx++\n\treturn\n}\n",
+			b:      "func f() {\n\treturn\n}\n",
+			before: "x++",
+			after:  "",
+		},
+		{
+			name: "too many lines",
+			a:    "a\n1\n2\n3\n4\nb\n",
+			b:    "a\nb\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := mutatedLines([]byte(tt.a), []byte(tt.b))
+			if before != tt.before || after != tt.after {
+				t.Errorf("mutatedLines() = %q, %q, want %q, %q", before, after, tt.before, tt.after)
+			}
+		})
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+		ok    bool
+	}{
+		{"file", "report.json", true},
+		{"nested", "mutants/1/mutant.diff", true},
+		{"parent", "../x", false},
+		{"nested parent", "mutants/../../x", false},
+		{"absolute", "/etc/x", false},
+		{"backslashes", `..\..\x`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "bundle.tar.gz")
+			f, err := os.Create(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gz := gzip.NewWriter(f)
+			tw := tar.NewWriter(gz)
+			err = tw.WriteHeader(&tar.Header{Name: tt.entry, Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+			if err == nil {
+				_, err = tw.Write([]byte("x"))
+			}
+			for _, c := range []interface{ Close() error }{tw, gz, f} {
+				if closeErr := c.Close(); err == nil {
+					err = closeErr
+				}
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target := filepath.Join(dir, "out")
+			err = unpack(filename, target)
+			if tt.ok && err != nil {
+				t.Errorf("unpack() of %s = %s, want no error", tt.entry, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("unpack() of %s passed, want an error", tt.entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/danicat/selene/internal/mutator"
+)
+
+// cachedMutant is a mutant as stored in the scan cache. The mutator is
+// stored by name, as it can't be serialized.
+type cachedMutant struct {
+	ID      string
+	Pos     token.Position
+	Mutator string
+	Index   int
+	Variant int
+}
+
+// scanCacheDir returns the directory of the scan cache, or an empty string
+// if there is no user cache directory.
+func scanCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "selene", "scan")
+}
+
+// scanKey identifies the scan of a file: its content and everything else
+// the mutants found depend on.
+func scanKey(filename string, content []byte, mutators []mutator.Mutator, funcs map[int]bool) string {
+	h := sha256.New()
+	h.Write(content)
+	// IDs embed the path as displayed
+	fmt.Fprintf(h, "\x00%s\x00", displayPath(filename))
+
+	for _, m := range mutators {
+		fmt.Fprintf(h, "%s@%s\x00", m.Name, m.Version)
+		if m.Settings != nil {
+			fmt.Fprintf(h, "%s\x00", m.Settings())
+		}
+	}
+
+	if funcs != nil {
+		var lines []int
+		for line := range funcs {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		fmt.Fprintf(h, "funcs %v", lines)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedScan returns the mutants of filename like scanMutants, reusing the
+// result of a previous scan if the file didn't change since. The cache is
+// best effort: if it can't be read or written the file is just scanned.
+func cachedScan(ctx *build.Context, filename string, mutators []mutator.Mutator, funcs map[int]bool) ([]mutant, error) {
+	entry, err := scanCacheEntry(ctx, filename, mutators, funcs)
+	if err != nil {
+		return nil, err
+	}
+	if entry == "" {
+		return scanMutants(ctx, filename, mutators, funcs)
+	}
+
+	if mutants, ok := readScanCache(entry, filename, mutators, funcs); ok {
+		log.Printf("scan cache hit: %s", filename)
+		return mutants, nil
+	}
+
+	return refreshScan(ctx, entry, filename, mutators, funcs)
+}
+
+// rescan scans filename again, replacing its entry in the scan cache, for
+// mutants of a stale entry not found at their positions.
+func rescan(ctx *build.Context, filename string, mutators []mutator.Mutator, funcs map[int]bool) ([]mutant, error) {
+	entry, err := scanCacheEntry(ctx, filename, mutators, funcs)
+	if err != nil {
+		return nil, err
+	}
+	if entry == "" {
+		return scanMutants(ctx, filename, mutators, funcs)
+	}
+
+	log.Printf("scan cache stale: %s", filename)
+	return refreshScan(ctx, entry, filename, mutators, funcs)
+}
+
+// scanCacheEntry returns the scan cache file of filename, or an empty
+// string if there is no cache or its key can't be found.
+func scanCacheEntry(ctx *build.Context, filename string, mutators []mutator.Mutator, funcs map[int]bool) (string, error) {
+	cacheDir := scanCacheDir()
+	if cacheDir == "" {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	// typed mutants depend on the whole package, and on the packages it
+	// imports
+	if needsTypes(mutators) {
+		pkgContent, err := packageContent(ctx, filename)
+		if err != nil {
+			return "", nil
+		}
+		depKey, err := importedKey(ctx, filename)
+		if err != nil {
+			return "", nil
+		}
+		content = append(content, pkgContent...)
+		content = append(content, depKey...)
+	}
+
+	return filepath.Join(cacheDir, scanKey(filename, content, mutators, funcs)+".json"), nil
+}
+
+// refreshScan scans filename and writes the mutants found to entry.
+func refreshScan(ctx *build.Context, entry, filename string, mutators []mutator.Mutator, funcs map[int]bool) ([]mutant, error) {
+	mutants, err := scanMutants(ctx, filename, mutators, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := make([]cachedMutant, len(mutants))
+	for i, mt := range mutants {
+		cached[i] = cachedMutant{
+			ID:      mt.ID,
+			Pos:     mt.Pos,
+			Mutator: mt.Mutator.Name,
+			Index:   mt.Index,
+			Variant: mt.Variant,
+		}
+	}
+
+	bytes, err := json.Marshal(cached)
+	if err == nil && os.MkdirAll(filepath.Dir(entry), os.ModePerm) == nil {
+		err = os.WriteFile(entry, bytes, 0o644)
+	}
+	if err != nil {
+		log.Printf("failed to write scan cache: %s", err)
+	}
+
+	return mutants, nil
+}
+
+func readScanCache(entry, filename string, mutators []mutator.Mutator, funcs map[int]bool) ([]mutant, bool) {
+	bytes, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, false
+	}
+
+	var cached []cachedMutant
+	err = json.Unmarshal(bytes, &cached)
+	if err != nil {
+		return nil, false
+	}
+
+	byName := map[string]mutator.Mutator{}
+	for _, m := range mutators {
+		byName[m.Name] = m
+	}
+
+	mutants := make([]mutant, len(cached))
+	for i, c := range cached {
+		m, ok := byName[c.Mutator]
+		if !ok {
+			return nil, false
+		}
+
+		mutants[i] = mutant{
+			ID:      c.ID,
+			File:    filename,
+			Pos:     c.Pos,
+			Mutator: m,
+			Index:   c.Index,
+			Variant: c.Variant,
+			Funcs:   funcs,
+		}
+	}
+
+	return mutants, true
+}
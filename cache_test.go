@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danicat/selene/internal/mutator"
+)
+
+// writeModule writes files, by path relative to a new module directory,
+// and returns the directory.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files["go.mod"] = "module example\n\ngo 1.21\n"
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// The mutants of typed mutators depend on the types of the packages the
+// file imports, so changing them changes the cache entry.
+func TestScanCacheEntryDependencies(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := writeModule(t, map[string]string{
+		"a/a.go": "package a\n\nimport \"example/b\"\n\nfunc F() int { return b.N }\n",
+		"b/b.go": "package b\n\nimport \"example/c\"\n\nconst N = c.N\n",
+		"c/c.go": "package c\n\nconst N = 1\n",
+	})
+	filename := filepath.Join(dir, "a", "a.go")
+	// the go command finds the module from the working directory
+	ctx := toolchain{}.buildContext()
+	ctx.Dir = dir
+	mutators := []mutator.Mutator{{Name: "Typed", Version: "1.0.0", Types: true}}
+
+	before, err := scanCacheEntry(ctx, filename, mutators, nil)
+	if err != nil || before == "" {
+		t.Fatalf("scanCacheEntry() = %q, %v", before, err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "c", "c.go"), []byte("package c\n\nconst N = \"1\"\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// as in a later run
+	importedKeys.byDir = nil
+	after, err := scanCacheEntry(ctx, filename, mutators, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Errorf("scanCacheEntry() = %s once an indirect dependency changed, want another entry", after)
+	}
+}
+
+// Mutants of a stale cache entry are found again by scanning the file,
+// instead of failing the run.
+func TestRescanned(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := writeModule(t, map[string]string{
+		"a/a.go": "package a\n\nfunc F(x, y int) bool { return x > 0 && y > 0 }\n",
+	})
+	filename := filepath.Join(dir, "a", "a.go")
+	ctx := toolchain{}.buildContext()
+	r := &runner{mutators: mutator.Defaults()}
+
+	mutants, err := cachedScan(ctx, filename, r.mutators, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := scanCacheEntry(ctx, filename, r.mutators, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// as if the candidates were counted differently when cached
+	var cached []cachedMutant
+	b, err := os.ReadFile(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &cached); err != nil {
+		t.Fatal(err)
+	}
+	i, j := -1, -1
+	for k, c := range cached {
+		for l := range cached[:k] {
+			if cached[l].Mutator == c.Mutator && cached[l].Index != c.Index {
+				i, j = l, k
+			}
+		}
+	}
+	if i < 0 {
+		t.Fatalf("no two candidates of the same mutator in %s", filename)
+	}
+	cached[i].Index, cached[j].Index = cached[j].Index, cached[i].Index
+	b, err = json.Marshal(cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entry, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := cachedScan(ctx, filename, r.mutators, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := writeMutant(ctx, stale[i], t.TempDir()); !errors.As(err, new(*misplacedError)) {
+		t.Fatalf("writeMutant() of a stale mutant = %v, want a misplacedError", err)
+	}
+
+	mt, err := r.rescanned(stale[i])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.Index != mutants[i].Index {
+		t.Errorf("rescanned() index = %d, want %d", mt.Index, mutants[i].Index)
+	}
+	if _, _, err := writeMutant(ctx, mt, t.TempDir()); err != nil {
+		t.Errorf("writeMutant() of the rescanned mutant: %s", err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// compare diffs the mutants of two runs, either from their JSON reports or
+// by running selene on two git refs. The remaining arguments are passed
+// to the runs, as in selene compare --before-ref main run-all --mode quick.
+func compare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	before := fs.String("before", "", "JSON `report` of the run before the change")
+	after := fs.String("after", "", "JSON `report` of the run after the change")
+	beforeRef := fs.String("before-ref", "", "git `ref` to run before the change, instead of --before")
+	afterRef := fs.String("after-ref", "", "git `ref` to run after the change, instead of --after (default the working tree)")
+	format := fs.String("format", "text", "output format: text or json")
+	err := fs.Parse(args)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if *format != "text" && *format != "json" {
+		return &ConfigError{Err: fmt.Errorf("unknown format %q, expected text or json", *format)}
+	}
+
+	switch {
+	case *before != "" && *beforeRef != "", *after != "" && *afterRef != "":
+		return &ConfigError{Err: fmt.Errorf("a report and a ref can't be given for the same side")}
+	case *before == "" && *beforeRef == "":
+		return &ConfigError{Err: fmt.Errorf("usage: selene compare --before <report.json> --after <report.json>\n       selene compare --before-ref <ref> [--after-ref <ref>] [run|run-all] [flags] [files]")}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "compare")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if *beforeRef != "" {
+		*before = filepath.Join(tmpDir, "before.json")
+		err = runAtRef(*beforeRef, *before, fs.Args())
+		if err != nil {
+			return err
+		}
+	}
+
+	if *after == "" {
+		*after = filepath.Join(tmpDir, "after.json")
+		err = runAtRef(*afterRef, *after, fs.Args())
+		if err != nil {
+			return err
+		}
+	}
+
+	beforeDoc, err := report.ReadDocument(*before)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	afterDoc, err := report.ReadDocument(*after)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	c := report.Compare(beforeDoc, afterDoc)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(c)
+	} else {
+		err = writeComparison(os.Stdout, c)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(c.NewlySurviving) > 0 {
+		return &RegressionError{NewlySurviving: len(c.NewlySurviving)}
+	}
+
+	return nil
+}
+
+// runAtRef runs selene with args on a checkout of ref, or on the working
+// tree if ref is empty, and writes its JSON report to reportFile. The
+// checkout is a temporary git worktree, so the working tree is left alone.
+func runAtRef(ref, reportFile string, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if ref != "" {
+		top := git("rev-parse", "--show-toplevel")
+		if top == "" {
+			return &ConfigError{Err: fmt.Errorf("--before-ref and --after-ref need a git repository")}
+		}
+
+		rel, err := filepath.Rel(top, dir)
+		if err != nil {
+			return err
+		}
+
+		worktree, err := os.MkdirTemp("", "worktree")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(worktree)
+
+		out, err := exec.Command("git", "worktree", "add", "--detach", worktree, ref).CombinedOutput()
+		if err != nil {
+			return &ConfigError{Err: fmt.Errorf("failed to check out %s: %s", ref, strings.TrimSpace(string(out)))}
+		}
+		defer exec.Command("git", "worktree", "remove", "--force", worktree).Run()
+
+		// run from the same place within the checkout, so mutant IDs
+		// match between both sides
+		dir = filepath.Join(worktree, rel)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// reports go right after the command, if any, as flags must come
+	// before the files
+	var runArgs []string
+	if len(args) > 0 && (args[0] == "run" || args[0] == "run-all") {
+		runArgs, args = append(runArgs, args[0]), args[1:]
+	}
+	runArgs = append(runArgs, "--report", "console", "--report", "json="+reportFile)
+	runArgs = append(runArgs, args...)
+
+	name := ref
+	if name == "" {
+		name = "working tree"
+	}
+	fmt.Printf("# %s\n", name)
+
+	cmd := exec.Command(self, runArgs...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// each run needs its own mutation directory
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, GOMUTATION+"=") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == exitThreshold {
+		// survivors are what we are comparing
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("run on %s failed: %s", name, err)
+	}
+
+	return nil
+}
+
+func writeComparison(w io.Writer, c report.Comparison) error {
+	sections := []struct {
+		title   string
+		changes []report.Change
+	}{
+		{"newly killed", c.NewlyKilled},
+		{"newly surviving", c.NewlySurviving},
+		{"added", c.Added},
+		{"removed", c.Removed},
+	}
+
+	for _, s := range sections {
+		fmt.Fprintf(w, "%s: %d\n", s.title, len(s.changes))
+		for _, change := range s.changes {
+			if change.Before == "" {
+				fmt.Fprintf(w, "    %s (%s)\n", change.ID, change.After)
+			} else {
+				fmt.Fprintf(w, "    %s (was %s)\n", change.ID, change.Before)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// parallelTests counts the tests of the package in dir, and how many of
+// them call t.Parallel.
+func parallelTests(ctx *build.Context, dir string) (parallel, total int, err error) {
+	bpkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range append(bpkg.TestGoFiles, bpkg.XTestGoFiles...) {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil || !strings.HasPrefix(fn.Name.Name, "Test") || !isTest(fn.Type) {
+				continue
+			}
+
+			total++
+			if callsParallel(fn) {
+				parallel++
+			}
+		}
+	}
+
+	return parallel, total, nil
+}
+
+func callsParallel(fn *ast.FuncDecl) bool {
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found {
+			return !found
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if ok && sel.Sel.Name == "Parallel" && len(call.Args) == 0 {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// concurrency decides how many mutants of the package in dir run at once
+// and the go test flags limiting each run, so that together they don't
+// oversubscribe the CPUs and slow tests down into timeouts. When the
+// baseline was measured, each mutant is expected to keep as many CPUs
+// busy and to need as much memory as it did, and as many run at once as
+// the CPUs and the available memory allow. Otherwise suites where most
+// tests call t.Parallel are taken to use every CPU on their own, so they
+// get fewer workers. Those suites get a share of the CPUs for each run.
+// --workers and --parallel override the decision.
+func (r *runner) concurrency(dir string) (int, []string) {
+	cpus := runtime.NumCPU()
+
+	parallel, total, err := parallelTests(r.opts.toolchain.buildContext(), dir)
+	if err != nil {
+		log.Printf("%s: failed to scan tests for t.Parallel: %s", dir, err)
+	}
+	heavy := total > 0 && parallel*2 >= total
+
+	workers := r.opts.workers
+	if workers <= 0 {
+		workers = cpus
+		if heavy {
+			workers = max(1, cpus/2)
+		}
+
+		if u, ok := r.usage[dir]; ok && u.wall > 0 && u.cpu > 0 {
+			workers = calibrate(dir, u, cpus, availableMemory())
+		}
+	}
+
+	perRun := r.opts.parallel
+	if perRun <= 0 {
+		if !heavy || workers == 1 {
+			log.Printf("%s: %d of %d tests call t.Parallel, running %d workers", dir, parallel, total, workers)
+			return workers, nil
+		}
+		perRun = max(1, cpus/workers)
+	}
+
+	log.Printf("%s: %d of %d tests call t.Parallel, running %d workers with -p %d -parallel %d", dir, parallel, total, workers, perRun, perRun)
+
+	n := strconv.Itoa(perRun)
+	return workers, []string{"-p", n, "-parallel", n}
+}
+
+// calibrate returns how many mutants can run at once given the usage of
+// the baseline, with memory bytes available, 0 if unknown.
+func calibrate(dir string, u testUsage, cpus int, memory int64) int {
+	busy := max(1, u.cpu.Seconds()/u.wall.Seconds())
+	workers := max(1, int(float64(cpus)/busy+0.5))
+	log.Printf("%s: the baseline kept %.1f CPUs busy, %d workers fit %d CPUs", dir, busy, workers, cpus)
+
+	if memory > 0 && u.maxRSS > 0 {
+		byMemory := max(1, int(memory/u.maxRSS))
+		log.Printf("%s: the baseline peaked at %d MiB, %d workers fit %d MiB available", dir, u.maxRSS>>20, byMemory, memory>>20)
+		workers = min(workers, byMemory)
+	}
+
+	return workers
+}
+
+// availableMemory returns the memory available for new processes in
+// bytes, as estimated by Linux, or 0 elsewhere.
+func availableMemory() int64 {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "MemAvailable:"); ok {
+			kb, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "kB")), 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+)
+
+// defaultConfig is read when present, without needing --config.
+const defaultConfig = "selene.json"
+
+// config holds the settings meant to be shared by a team, so they live in
+// a file checked in with the code instead of on the command line.
+type config struct {
+	Exclude struct {
+		// Functions are regular expressions matched against the names of
+		// functions and methods, both as Func or Type.Method and fully
+		// qualified with the package path.
+		Functions []string `json:"functions"`
+	} `json:"exclude"`
+
+	Mutators struct {
+		Retry struct {
+			// Names are regular expressions matched against the names of
+			// retry counters and limits, replacing the default ones.
+			Names []string `json:"names"`
+		} `json:"retry"`
+
+		GoStatement struct {
+			// Remove also removes the calls of go statements, besides
+			// making them synchronous.
+			Remove bool `json:"remove"`
+		} `json:"goStatement"`
+	} `json:"mutators"`
+
+	// Thresholds are the minimum mutation scores by package pattern,
+	// with default for the packages no pattern matches.
+	Thresholds thresholds `json:"thresholds"`
+
+	// Expensive are the tests only run in deep mode, on the mutants the
+	// others let survive.
+	Expensive struct {
+		// Tags are the build tags of their files, as in integration.
+		Tags []string `json:"tags"`
+		// Tests are regular expressions matched against their names,
+		// as with go test -run.
+		Tests []string `json:"tests"`
+	} `json:"expensive"`
+
+	// Test runs the tests through a wrapper instead of go test -json.
+	Test testAdapter `json:"test"`
+
+	// Env controls the environment of the go commands and the tests.
+	Env environment `json:"env"`
+
+	// Presets are run settings selected with --mode, by name, besides or
+	// instead of the built-in ones.
+	Presets map[string]preset `json:"presets"`
+}
+
+// loadConfig reads the config file. A missing default file is the same
+// as an empty config.
+func loadConfig(filename string) (*config, error) {
+	cfg := &config{}
+
+	bytes, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) && filename == defaultConfig {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("failed to read config: %s", err)}
+	}
+
+	err = json.Unmarshal(bytes, cfg)
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("invalid config %s: %s", filename, err)}
+	}
+
+	return cfg, nil
+}
+
+// compilePatterns compiles the regular expressions of a config rule.
+func compilePatterns(rule string, patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, &ConfigError{Err: fmt.Errorf("invalid %s pattern: %s", rule, err)}
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
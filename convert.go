@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// convert rewrites a JSON report in another schema version, so consumers
+// that only know older ones keep working across selene upgrades.
+func convert(args []string) error {
+	fs := flag.NewFlagSet("report convert", flag.ContinueOnError)
+	to := fs.Int("to", report.SchemaVersion, "schema `version` to convert to")
+	output := fs.String("o", "", "`file` to write the converted report to (default stdout)")
+	err := fs.Parse(args)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if fs.NArg() != 1 {
+		return &ConfigError{Err: fmt.Errorf("usage: selene report convert [-to %d] [-o file] <report.json>", report.SchemaVersion)}
+	}
+
+	doc, err := report.ReadLiveDocument(fs.Arg(0))
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	doc, err = report.Convert(doc, *to)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
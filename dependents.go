@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build"
+	"sort"
+	"strings"
+)
+
+// hasTestFiles reports whether the package in dir has test files of its own.
+func hasTestFiles(ctx *build.Context, dir string) (bool, error) {
+	pkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(pkg.TestGoFiles)+len(pkg.XTestGoFiles) > 0, nil
+}
+
+// modulePackage returns the import path of the package in dir and the
+// directory of its module.
+func (tc toolchain) modulePackage(dir string) (string, string, error) {
+	out, err := tc.command(dir, "list", "-f", "{{.ImportPath}} {{.Module.Dir}}", ".").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get module of %s: %s", dir, err)
+	}
+	pkgPath, moduleDir, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	return pkgPath, moduleDir, nil
+}
+
+// testImporters returns the reverse test dependencies of the packages of
+// the module in moduleDir: by import path, the packages whose tests
+// depend on it, sorted. A package counts whether its tests import it or
+// the package itself does, directly or through other packages, so
+// libraries tested through their importers are found along with test
+// helpers.
+func (tc toolchain) testImporters(moduleDir string) (map[string][]string, error) {
+	// test variants of packages carry the import path of the package
+	// they test, and their dependencies those of the package and of its
+	// test files
+	cmd := tc.command(moduleDir, "list", "-test", "-f", "{{if .ForTest}}{{.ForTest}}{{range .Deps}} {{.}}{{end}}{{end}}", "./...")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	found := map[string]map[string]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// dependencies built for a test are listed as "path [pkg.test]",
+		// their path is a field of its own all the same
+		for _, dep := range fields[1:] {
+			if dep == fields[0] || strings.HasPrefix(dep, "[") {
+				continue
+			}
+			if found[dep] == nil {
+				found[dep] = map[string]bool{}
+			}
+			found[dep][fields[0]] = true
+		}
+	}
+
+	importers := map[string][]string{}
+	for dep, pkgs := range found {
+		for pkg := range pkgs {
+			importers[dep] = append(importers[dep], pkg)
+		}
+		sort.Strings(importers[dep])
+	}
+
+	return importers, nil
+}
+
+// testedBy returns the packages whose tests decide the fate of the
+// mutants of the package in dir: none if it has tests of its own, as
+// then only those run, and otherwise those whose tests depend on it. The
+// reverse dependencies are indexed once per module.
+func (r *runner) testedBy(dir string) ([]string, error) {
+	if dependents, ok := r.dependents[dir]; ok {
+		return dependents, nil
+	}
+
+	tested, err := hasTestFiles(r.opts.toolchain.buildContext(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan tests: %s", err)
+	}
+
+	var dependents []string
+	if !tested {
+		pkgPath, moduleDir, err := r.opts.toolchain.modulePackage(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		importers, ok := r.importers[moduleDir]
+		if !ok {
+			importers, err = r.opts.toolchain.testImporters(moduleDir)
+			if err != nil {
+				return nil, err
+			}
+			r.importers[moduleDir] = importers
+		}
+		dependents = importers[pkgPath]
+	}
+
+	r.dependents[dir] = dependents
+	return dependents, nil
+}
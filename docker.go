@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dockerImage is the golang image running the go commands with --docker,
+// pinned so runs are reproducible whatever the host has installed. The
+// Dockerfile of the CI image uses the same one.
+const dockerImage = "golang:1.23.2"
+
+// dockerCache is where the go build and module caches live in the
+// container, mounted from the user cache directory so they outlive it.
+const dockerCache = "/selene-cache"
+
+// dockerEnv are the host variables passed on to the go commands in the
+// container, besides the target platform.
+var dockerEnv = []string{
+	"CGO_ENABLED", "GOEXPERIMENT", "GOFLAGS", "GONOPROXY", "GONOSUMDB",
+	"GOPRIVATE", "GOPROXY", "GOSUMDB", "GOTOOLCHAIN",
+}
+
+// pullImage pulls the image of the toolchain, so the first go command
+// doesn't stall on it and a missing docker fails the run up front.
+func (tc toolchain) pullImage() error {
+	if runtime.GOOS == "windows" {
+		return &ConfigError{Err: fmt.Errorf("--docker needs a Linux or macOS host")}
+	}
+	if tc.goBin != "go" {
+		return &ConfigError{Err: fmt.Errorf("--go can't be used with --docker, which uses the go of %s", tc.docker)}
+	}
+
+	cmd := exec.Command("docker", "pull", "--quiet", tc.docker)
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return &ConfigError{Err: fmt.Errorf("failed to pull %s: %s", tc.docker, err)}
+	}
+
+	return nil
+}
+
+// dockerArgs returns the docker run command running go with args in dir
+// inside the container. The module of dir and the files of the overlay
+// are mounted at the same paths, so paths mean the same on both sides.
+func (tc toolchain) dockerArgs(dir string, args ...string) []string {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	dir, _ = filepath.Abs(dir)
+
+	mounts := []string{moduleRoot(dir)}
+	for _, arg := range args {
+		if overlay, ok := strings.CutPrefix(arg, "-overlay="); ok {
+			mounts = append(mounts, overlayDirs(overlay)...)
+		}
+	}
+
+	run := []string{"docker", "run", "--rm", "--user", strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())}
+	for _, m := range nestedOut(mounts) {
+		run = append(run, "-v", m+":"+m)
+	}
+
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(cacheDir, "selene", "docker")
+		if os.MkdirAll(cacheDir, os.ModePerm) == nil {
+			run = append(run, "-v", cacheDir+":"+dockerCache)
+		}
+	}
+	run = append(run, "-e", "HOME=/tmp", "-e", "GOCACHE="+dockerCache+"/build", "-e", "GOMODCACHE="+dockerCache+"/mod")
+	env := append(tc.env(), tc.moduleEnv(dir)...)
+	for _, name := range dockerEnv {
+		_, ok := os.LookupEnv(name)
+		if ok && tc.environment.passes(name) && !slices.ContainsFunc(env, func(e string) bool { return strings.HasPrefix(e, name+"=") }) {
+			run = append(run, "-e", name)
+		}
+	}
+	for _, e := range env {
+		run = append(run, "-e", e)
+	}
+
+	run = append(run, "-w", dir, tc.docker, "go")
+	return append(run, args...)
+}
+
+// moduleRoot returns the directory of the workspace or module containing
+// dir, or dir itself outside of any.
+func moduleRoot(dir string) string {
+	root := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.work")); err == nil {
+			return d
+		}
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil && root == "" {
+			root = d
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if root == "" {
+		return dir
+	}
+	return root
+}
+
+// overlayDirs returns the directories of an overlay file and of the files
+// replacing others in it.
+func overlayDirs(overlay string) []string {
+	dirs := []string{filepath.Dir(overlay)}
+
+	data, err := os.ReadFile(overlay)
+	if err != nil {
+		return dirs
+	}
+	var o struct{ Replace map[string]string }
+	if json.Unmarshal(data, &o) != nil {
+		return dirs
+	}
+	for _, to := range o.Replace {
+		if to != "" {
+			dirs = append(dirs, filepath.Dir(to))
+		}
+	}
+
+	return dirs
+}
+
+// nestedOut drops the directories inside others of dirs, and duplicates.
+func nestedOut(dirs []string) []string {
+	sort.Strings(dirs)
+
+	var outer []string
+	for _, d := range dirs {
+		inside := slices.ContainsFunc(outer, func(o string) bool {
+			return d == o || strings.HasPrefix(d, o+string(filepath.Separator))
+		})
+		if !inside {
+			outer = append(outer, d)
+		}
+	}
+
+	return outer
+}
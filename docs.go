@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/danicat/selene/internal/mutator"
+)
+
+// docs generates user facing documentation from the code, so it can't get
+// out of sync with it. The only topic so far is the mutator reference.
+func docs(args []string) error {
+	if len(args) == 0 || args[0] != "mutators" {
+		return &ConfigError{Err: fmt.Errorf("usage: selene docs mutators [--format markdown|json]")}
+	}
+
+	fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "output format: markdown or json")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	switch *format {
+	case "markdown":
+		return writeMutatorsMarkdown(os.Stdout, mutator.All())
+	case "json":
+		return writeMutatorsJSON(os.Stdout, mutator.All())
+	default:
+		return &ConfigError{Err: fmt.Errorf("unknown format %q, expected markdown or json", *format)}
+	}
+}
+
+func writeMutatorsMarkdown(w io.Writer, mutators []mutator.Mutator) error {
+	fmt.Fprintln(w, "# Mutators")
+	for _, m := range mutators {
+		fmt.Fprintf(w, "\n## %s\n\nVersion %s. %s\n\n", m.Name, m.Version, m.Description)
+		if len(m.Packs) > 0 {
+			fmt.Fprintf(w, "Packs: %s.\n\n", strings.Join(m.Packs, ", "))
+		}
+		if m.OptIn {
+			fmt.Fprintf(w, "Opt-in: only runs when named in `--mutators` or `--packs`.\n\n")
+		}
+		if m.Slow {
+			fmt.Fprintf(w, "Slow: its mutants are often only killed by the go test timeout, so presets skipping slow mutators, as quick, leave it out unless named in `--mutators`.\n\n")
+		}
+		fmt.Fprintf(w, "```go\n// before\n%s\n\n// after\n%s\n```\n", m.Before, m.After)
+	}
+	return nil
+}
+
+func writeMutatorsJSON(w io.Writer, mutators []mutator.Mutator) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(mutators)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danicat/selene/internal/mutator"
+)
+
+// Every mutant of a file embedding another one keeps the go:embed
+// directive, or the embedded variable is empty and tests fail for the
+// wrong reason.
+func TestEmbedMutants(t *testing.T) {
+	filename, err := filepath.Abs(filepath.Join("testdata", "embed", "embed.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := toolchain{}.buildContext()
+
+	mutants, err := scanMutants(ctx, filename, mutator.Defaults(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mutants) == 0 {
+		t.Fatalf("no mutants found in %s", filename)
+	}
+
+	for i, mt := range mutants {
+		dir := filepath.Join(t.TempDir(), strconv.Itoa(i))
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+
+		replaced, _, err := writeMutant(ctx, mt, dir)
+		if err != nil {
+			t.Fatalf("mutant %s: %s", mt.ID, err)
+		}
+		if got := embedDirective(t, replaced[filename], "greeting"); got != "//go:embed greeting.txt" {
+			t.Errorf("mutant %s: greeting is embedded with %q, want //go:embed greeting.txt", mt.ID, got)
+		}
+	}
+}
+
+// The mutated copies are in another directory than the package, the
+// overlay makes the go command resolve embed patterns relative to the
+// original.
+func TestEmbedOverlay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	pkgDir, err := filepath.Abs(filepath.Join("testdata", "embed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(pkgDir, "embed.go")
+	dir := t.TempDir()
+
+	// printed as mutants are, without a mutation
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := writeFile(fset, file, filepath.Join(dir, "embed.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay, err := writeOverlay(filepath.Join(dir, "overlay.json"), map[string]string{filename: copied})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := toolchain{goBin: "go"}
+	tests, err := tc.runGoTest(pkgDir, overlay, filepath.Join(dir, "gotest.log.gz"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failedBuild, failedTests := failures(tests); failedBuild != "" || len(failedTests) > 0 || packageFailed(tests) {
+		t.Fatalf("go test of the copy failed: build %q, tests %q", failedBuild, failedTests)
+	}
+	if !slices.ContainsFunc(tests, func(e TestEvent) bool { return e.Test == "TestGreet" && e.Action == "pass" }) {
+		t.Error("TestGreet didn't pass with the copy")
+	}
+}
+
+// embedDirective returns the go:embed directive of the variable name
+// declared in the file at filename, empty if there is none.
+func embedDirective(t *testing.T, filename, name string) string {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR || gen.Doc == nil {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, id := range spec.(*ast.ValueSpec).Names {
+				if id.Name != name {
+					continue
+				}
+				for _, c := range gen.Doc.List {
+					if strings.HasPrefix(c.Text, "//go:embed ") {
+						return c.Text
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// environment controls the variables the go commands and the tests they
+// run get from the host, so results don't depend on the machine or leak
+// state between runs.
+type environment struct {
+	// Allow are the only host variables passed on, besides those the go
+	// command needs, or all of them if empty. A trailing * matches any
+	// suffix, as in AWS_*.
+	Allow []string `json:"allow"`
+	// Deny are host variables never passed on, even if allowed.
+	Deny []string `json:"deny"`
+	// Isolate gives every go test run a HOME and TMPDIR of its own,
+	// removed once it finishes. The go caches and config stay those of
+	// the host.
+	Isolate bool `json:"isolate"`
+
+	pinned []string // go variables depending on HOME, when isolated
+}
+
+// requiredEnv are the host variables passed on even if not allowed, as the
+// go command can't run without them. Those starting with GO and CGO_ are
+// passed on too.
+var requiredEnv = []string{"PATH", "HOME", "USERPROFILE", "SYSTEMROOT", "TMPDIR", "TMP", "TEMP"}
+
+// pinnedEnv are the go variables whose defaults are found from HOME, kept
+// at their host values when isolated.
+var pinnedEnv = []string{"GOCACHE", "GOMODCACHE", "GOPATH", "GOENV"}
+
+// check validates the environment and returns it, or nil if it leaves the
+// host variables alone.
+func (e environment) check(tc toolchain) (*environment, error) {
+	for _, name := range append(e.Allow, e.Deny...) {
+		if name == "" || strings.Contains(strings.TrimSuffix(name, "*"), "*") {
+			return nil, &ConfigError{Err: fmt.Errorf("invalid env variable %q, expected a name or a prefix ending in *", name)}
+		}
+	}
+
+	if len(e.Allow) == 0 && len(e.Deny) == 0 && !e.Isolate {
+		return nil, nil
+	}
+
+	// containers are isolated already, each go command runs in a new one
+	if e.Isolate && tc.docker == "" {
+		out, err := tc.command("", append([]string{"env"}, pinnedEnv...)...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get go env: %s", err)
+		}
+		for i, value := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			e.pinned = append(e.pinned, pinnedEnv[i]+"="+value)
+		}
+	}
+
+	return &e, nil
+}
+
+// passes reports whether the host variable name is passed on.
+func (e *environment) passes(name string) bool {
+	if e == nil {
+		return true
+	}
+	// set by selene itself, the tests of packages guarded by selenetest
+	// would otherwise run it again without end
+	if name == "SELENETEST_NESTED" {
+		return true
+	}
+	if matchesEnv(name, e.Deny) {
+		return false
+	}
+	if len(e.Allow) == 0 || matchesEnv(name, e.Allow) || matchesEnv(name, requiredEnv) {
+		return true
+	}
+	return strings.HasPrefix(name, "GO") || strings.HasPrefix(name, "CGO_")
+}
+
+// matchesEnv reports whether name is one of patterns, or starts with the
+// prefix of one ending in *.
+func matchesEnv(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(name, prefix) || p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hostEnv returns the host variables passed on to the go commands.
+func (tc toolchain) hostEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if tc.environment.passes(name) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// isolate creates a HOME and a TMPDIR of their own for the go commands
+// of tc, and returns tc running with them and a function removing them.
+// tc is returned as is unless isolated.
+func (tc toolchain) isolate() (toolchain, func(), error) {
+	if tc.environment == nil || tc.environment.pinned == nil {
+		return tc, func() {}, nil
+	}
+
+	home, err := os.MkdirTemp("", "home")
+	if err != nil {
+		return tc, nil, err
+	}
+	tmp := filepath.Join(home, "tmp")
+	err = os.Mkdir(tmp, os.ModePerm)
+	if err != nil {
+		os.RemoveAll(home)
+		return tc, nil, err
+	}
+
+	tc.home = home
+	return tc, func() { os.RemoveAll(home) }, nil
+}
+
+// isolatedEnv returns the variables of the HOME and TMPDIR of tc, if
+// isolated.
+func (tc toolchain) isolatedEnv() []string {
+	if tc.home == "" {
+		return nil
+	}
+	tmp := filepath.Join(tc.home, "tmp")
+	env := []string{"HOME=" + tc.home, "USERPROFILE=" + tc.home, "TMPDIR=" + tmp, "TMP=" + tmp, "TEMP=" + tmp}
+	return append(env, tc.environment.pinned...)
+}
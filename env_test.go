@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestMatchesEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     bool
+	}{
+		{"HOME", []string{"HOME"}, true},
+		{"HOMEDIR", []string{"HOME"}, false},
+		{"AWS_REGION", []string{"AWS_*"}, true},
+		{"AWS", []string{"AWS_*"}, false},
+		{"ANYTHING", []string{"*"}, true},
+		{"PATH", nil, false},
+		{"PATH", []string{"HOME", "PA*"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := matchesEnv(tt.name, tt.patterns); got != tt.want {
+			t.Errorf("matchesEnv(%q, %q) = %v, want %v", tt.name, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestEnvironmentPasses(t *testing.T) {
+	tests := []struct {
+		name string
+		env  environment
+		want bool
+	}{
+		{"PATH", environment{}, true},
+		{"SECRET", environment{Deny: []string{"SECRET"}}, false},
+		{"EDITOR", environment{Allow: []string{"PATH"}}, false},
+		{"HOME", environment{Allow: []string{"PATH"}}, true},
+		{"GOPATH", environment{Allow: []string{"PATH"}}, true},
+		{"CGO_ENABLED", environment{Allow: []string{"PATH"}}, true},
+		{"SELENETEST_NESTED", environment{Allow: []string{"PATH"}}, true},
+		{"SELENETEST_NESTED", environment{Deny: []string{"SELENETEST_*"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.env.passes(tt.name); got != tt.want {
+			t.Errorf("%+v passes(%q) = %v, want %v", tt.env, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Exit codes are part of the command line contract: CI pipelines rely on
+// them to tell weak tests apart from a broken setup.
+const (
+	exitOK        = 0
+	exitThreshold = 1 // some mutants survived, or newly survive with compare
+	exitConfig    = 2 // invalid arguments or environment
+	exitBaseline  = 3 // tests fail even without mutations
+	exitBuild     = 4 // no mutant of a package compiles
+	exitInternal  = 5 // anything else, most likely a bug in selene
+)
+
+// ConfigError reports invalid arguments or environment.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// BaselineError reports tests that fail before any mutation is applied.
+// Mutation results are meaningless in this case.
+type BaselineError struct {
+	FailedBuild string
+	Failed      []string
+}
+
+func (e *BaselineError) Error() string {
+	if e.FailedBuild != "" {
+		return fmt.Sprintf("baseline build failed: %s", e.FailedBuild)
+	}
+	return fmt.Sprintf("baseline tests failed: %s", strings.Join(e.Failed, ", "))
+}
+
+// BuildError reports a package where none of the mutants compile.
+type BuildError struct {
+	Package string
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("build failed: %s", e.Package)
+}
+
+// ThresholdError reports mutants that survived the tests. With thresholds
+// configured, Scopes are those whose mutation score is too low.
+type ThresholdError struct {
+	Survived int
+	Total    int
+	Scopes   []scopeScore
+}
+
+func (e *ThresholdError) Error() string {
+	msg := fmt.Sprintf("%d out of %d mutants survived", e.Survived, e.Total)
+	for _, s := range e.Scopes {
+		msg += fmt.Sprintf("\n%s: mutation score %.1f%%, below %g%%", s.Scope, s.score(), s.Threshold)
+	}
+	return msg
+}
+
+// RegressionError reports mutants killed before a change that survive
+// after it.
+type RegressionError struct {
+	NewlySurviving int
+}
+
+func (e *RegressionError) Error() string {
+	return fmt.Sprintf("%d mutants newly survive", e.NewlySurviving)
+}
+
+// exitCode maps an error returned by run to the process exit code.
+func exitCode(err error) int {
+	var (
+		configErr     *ConfigError
+		baselineErr   *BaselineError
+		buildErr      *BuildError
+		thresholdErr  *ThresholdError
+		regressionErr *RegressionError
+	)
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &configErr):
+		return exitConfig
+	case errors.As(err, &baselineErr):
+		return exitBaseline
+	case errors.As(err, &buildErr):
+		return exitBuild
+	case errors.As(err, &thresholdErr), errors.As(err, &regressionErr):
+		return exitThreshold
+	default:
+		return exitInternal
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goTestValueFlags are the go test and build flags followed by a value
+// when it isn't given with =. They are needed to tell the package
+// patterns apart from flag values.
+var goTestValueFlags = map[string]bool{
+	"asmflags": true, "bench": true, "benchtime": true, "blockprofile": true,
+	"blockprofilerate": true, "C": true, "count": true, "coverpkg": true,
+	"covermode": true, "coverprofile": true, "cpu": true, "cpuprofile": true,
+	"exec": true, "fuzz": true, "fuzzminimizetime": true, "fuzztime": true,
+	"gccgoflags": true, "gcflags": true, "installsuffix": true, "ldflags": true,
+	"list": true, "memprofile": true, "memprofilerate": true, "mod": true,
+	"modfile": true, "mutexprofile": true, "mutexprofilefraction": true,
+	"o": true, "outputdir": true, "overlay": true, "p": true, "parallel": true,
+	"pgo": true, "pkgdir": true, "run": true, "shuffle": true, "skip": true,
+	"tags": true, "timeout": true, "toolexec": true, "trace": true, "vet": true,
+}
+
+// goBuildFlags are the go test flags that are build flags, which go list
+// takes as well, as they decide the files of the packages.
+var goBuildFlags = map[string]bool{
+	"asan": true, "asmflags": true, "buildvcs": true, "compiler": true,
+	"cover": true, "covermode": true, "coverpkg": true, "gccgoflags": true,
+	"gcflags": true, "installsuffix": true, "ldflags": true, "linkshared": true,
+	"mod": true, "modcacherw": true, "modfile": true, "msan": true, "pgo": true,
+	"race": true, "tags": true, "toolexec": true, "trimpath": true,
+}
+
+// outputFlags are the go test flags writing files, which every mutant run
+// would overwrite, or changing the output selene reads. The mutant runs
+// don't get them.
+var outputFlags = map[string]bool{
+	"blockprofile": true, "c": true, "coverprofile": true, "cpuprofile": true,
+	"json": true, "memprofile": true, "mutexprofile": true, "o": true,
+	"outputdir": true, "trace": true,
+}
+
+// runExec runs a go test command as given, as the baseline, and then the
+// mutation tests of the packages it tested. It lets a CI pipeline adopt
+// selene by prefixing its existing go test line.
+func runExec(opts options, command []string) error {
+	if len(command) < 2 || command[0] != "go" || command[1] != "test" {
+		return &ConfigError{Err: fmt.Errorf("usage: selene exec [flags] -- go test [flags] [packages]")}
+	}
+
+	// go only takes -C first, and the mutant runs are already made from
+	// the package directories
+	dir, args := changeDir(command[2:])
+	testFlags, buildFlags := testCommandFlags(args)
+	if slices.ContainsFunc(testFlags, func(f string) bool { return flagName(f) == "overlay" }) {
+		return &ConfigError{Err: fmt.Errorf("-overlay can't be given to go test, use selene --overlay instead")}
+	}
+
+	opts.toolchain.tags = buildTags(buildFlags)
+	r, err := newRunner(opts)
+	if err != nil {
+		return err
+	}
+	// the command is the baseline, its flags are those of every mutant
+	r.skipBaseline = true
+	r.goTestFlags = testFlags
+
+	// run as the mutants are, with the go command and environment of
+	// the toolchain of the runner, once the packages and their tests are
+	// built
+	patterns := testPatterns(args)
+	r.opts.toolchain.warmCache(dir, "", testFlags, patterns)
+	// the time of every package tested, for the mutant timeouts
+	r.commandElapsed, err = runCommand(r.opts.toolchain, command, os.Stdout, os.Stderr)
+	if err != nil {
+		return &BaselineError{Failed: []string{strings.Join(command, " ") + ": " + err.Error()}}
+	}
+
+	pkgs, err := testedPackages(r.opts.toolchain, dir, buildFlags, patterns)
+	if err != nil {
+		return err
+	}
+
+	mutationDir, err := makeMutationDir()
+	if err != nil {
+		return err
+	}
+
+	for i, pkg := range pkgs {
+		fmt.Printf("# %s\n", pkg.Dir)
+
+		pkgMutationDir := filepath.Join(mutationDir, strconv.Itoa(i))
+		err := os.MkdirAll(pkgMutationDir, os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		r.filenames = nil
+		for _, f := range pkg.Files {
+			r.filenames = append(r.filenames, displayPath(f))
+		}
+
+		err = r.testPackage(pkg.Files, pkgMutationDir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkg.Dir, err)
+		}
+	}
+
+	return r.finish()
+}
+
+// runCommand runs a go command as given with tc and returns how long it
+// took. It gets a HOME and TMPDIR of its own
+// if tc is isolated, as the mutant runs do.
+func runCommand(tc toolchain, command []string, stdout, stderr io.Writer) (time.Duration, error) {
+	tc, cleanup, err := tc.isolate()
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	cmd := tc.command("", command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	start := time.Now()
+	err = cmd.Run()
+	return time.Since(start), err
+}
+
+// changeDir returns the directory of the -C flag the go test arguments
+// start with, if any, and the arguments without it.
+func changeDir(args []string) (string, []string) {
+	if len(args) == 0 || flagName(args[0]) != "C" {
+		return "", args
+	}
+
+	if _, dir, ok := strings.Cut(args[0], "="); ok {
+		return dir, args[1:]
+	}
+	if len(args) < 2 {
+		return "", args[1:]
+	}
+	return args[1], args[2:]
+}
+
+// testPatterns returns the package patterns among go test arguments.
+// Arguments after the packages, or after -args, go to the test binary.
+func testPatterns(args []string) []string {
+	var patterns []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-args" || arg == "--args" {
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			patterns = append(patterns, arg)
+			continue
+		}
+
+		if !strings.Contains(arg, "=") && goTestValueFlags[flagName(arg)] {
+			i++ // skip the value
+		}
+	}
+
+	return patterns
+}
+
+// testCommandFlags returns the flags among go test arguments, with their
+// values: those the mutant runs get, leaving out outputFlags, and those
+// that are build flags. Arguments after the packages, or after -args, go
+// to the test binary and are left out.
+func testCommandFlags(args []string) (testFlags, buildFlags []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-args" || arg == "--args" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		flag := []string{arg}
+		name := flagName(arg)
+		if !strings.Contains(arg, "=") && goTestValueFlags[name] && i+1 < len(args) {
+			i++
+			flag = append(flag, args[i])
+		}
+
+		if !outputFlags[name] {
+			testFlags = append(testFlags, flag...)
+		}
+		if goBuildFlags[name] {
+			buildFlags = append(buildFlags, flag...)
+		}
+	}
+	return testFlags, buildFlags
+}
+
+// buildTags returns the tags of the -tags build flag, comma separated or,
+// as go used to take them, space separated.
+func buildTags(buildFlags []string) []string {
+	var tags []string
+	for i := 0; i < len(buildFlags); i++ {
+		if flagName(buildFlags[i]) != "tags" {
+			continue
+		}
+		_, value, ok := strings.Cut(buildFlags[i], "=")
+		if !ok && i+1 < len(buildFlags) {
+			i++
+			value = buildFlags[i]
+		}
+		// the last -tags wins, as with go
+		tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return tags
+}
+
+// flagName returns the name of a command line flag, without its dashes
+// and value.
+func flagName(arg string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name
+}
+
+// testedPackages lists the packages matching patterns in dir, the current
+// directory if empty, that have tests, with the files selected by the
+// build flags.
+func testedPackages(tc toolchain, dir string, buildFlags, patterns []string) ([]goPackage, error) {
+	args := append([]string{"list", "-json"}, buildFlags...)
+	args = append(args, patterns...)
+	cmd := tc.command(dir, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("go list failed: %s", strings.TrimSpace(stderr.String()))}
+	}
+
+	var pkgs []goPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p struct {
+			Dir          string
+			Module       struct{ Dir string }
+			GoFiles      []string
+			CgoFiles     []string
+			TestGoFiles  []string
+			XTestGoFiles []string
+		}
+		err := dec.Decode(&p)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(p.TestGoFiles)+len(p.XTestGoFiles) == 0 {
+			continue
+		}
+
+		pkg := goPackage{Module: p.Module.Dir, Dir: p.Dir, Tested: true}
+		for _, name := range append(p.GoFiles, p.CgoFiles...) {
+			pkg.Files = append(pkg.Files, filepath.Join(p.Dir, name))
+		}
+		if len(pkg.Files) > 0 {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	return pkgs, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestChangeDir(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		dir  string
+		rest []string
+	}{
+		{"none", []string{"-race", "./..."}, "", []string{"-race", "./..."}},
+		{"value", []string{"-C", "sub", "-race", "./..."}, "sub", []string{"-race", "./..."}},
+		{"value with =", []string{"-C=sub", "./..."}, "sub", []string{"./..."}},
+		{"double dash", []string{"--C", "sub", "."}, "sub", []string{"."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, rest := changeDir(tt.args)
+			if dir != tt.dir || !slices.Equal(rest, tt.rest) {
+				t.Errorf("changeDir(%q) = %q, %q, want %q, %q", tt.args, dir, rest, tt.dir, tt.rest)
+			}
+		})
+	}
+}
+
+func TestTestPatterns(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"none", nil, nil},
+		{"packages", []string{"./...", "./cmd"}, []string{"./...", "./cmd"}},
+		{"bool flags", []string{"-race", "-v", "."}, []string{"."}},
+		{"value flag", []string{"-run", "TestX", "./pkg"}, []string{"./pkg"}},
+		{"value flag with =", []string{"-run=TestX", "./pkg"}, []string{"./pkg"}},
+		{"double dash", []string{"--tags", "integration", "."}, []string{"."}},
+		{"args", []string{".", "-args", "extra"}, []string{"."}},
+		{"only flags", []string{"-count", "1", "-short"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testPatterns(tt.args); !slices.Equal(got, tt.want) {
+				t.Errorf("testPatterns(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestCommandFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		testFlags []string
+		build     []string
+	}{
+		{"none", []string{"./..."}, nil, nil},
+		{
+			"test and build flags",
+			[]string{"-race", "-count", "1", "-tags", "integration", "./..."},
+			[]string{"-race", "-count", "1", "-tags", "integration"},
+			[]string{"-race", "-tags", "integration"},
+		},
+		{
+			"output flags left out",
+			[]string{"-json", "-coverprofile=c.out", "-v", "-run=TestX", "."},
+			[]string{"-v", "-run=TestX"},
+			nil,
+		},
+		{
+			"binary args left out",
+			[]string{"-short", ".", "-args", "-flag", "value"},
+			[]string{"-short"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFlags, build := testCommandFlags(tt.args)
+			if !slices.Equal(testFlags, tt.testFlags) {
+				t.Errorf("testCommandFlags(%q) test flags = %q, want %q", tt.args, testFlags, tt.testFlags)
+			}
+			if !slices.Equal(build, tt.build) {
+				t.Errorf("testCommandFlags(%q) build flags = %q, want %q", tt.args, build, tt.build)
+			}
+		})
+	}
+}
+
+func TestBuildTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		want  []string
+	}{
+		{"none", []string{"-race"}, nil},
+		{"comma separated", []string{"-tags=a,b"}, []string{"a", "b"}},
+		{"space separated", []string{"-tags", "a b"}, []string{"a", "b"}},
+		{"last wins", []string{"-tags=a", "--tags", "b"}, []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTags(tt.flags); !slices.Equal(got, tt.want) {
+				t.Errorf("buildTags(%q) = %q, want %q", tt.flags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagName(t *testing.T) {
+	tests := map[string]string{
+		"-race":       "race",
+		"--tags":      "tags",
+		"-run=TestX":  "run",
+		"-ldflags=-s": "ldflags",
+	}
+
+	for arg, want := range tests {
+		if got := flagName(arg); got != want {
+			t.Errorf("flagName(%q) = %q, want %q", arg, got, want)
+		}
+	}
+}
+
+// The command of selene exec is the baseline of the mutants, so it runs
+// with the same environment: a test passing only with a denied variable
+// fails it.
+func TestRunCommandEnvironment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example\n\ngo 1.21\n",
+		"env_test.go": `package example
+
+import (
+	"os"
+	"testing"
+)
+
+func TestToken(t *testing.T) {
+	if os.Getenv("SELENE_EXEC_TOKEN") == "" {
+		t.Fatal("SELENE_EXEC_TOKEN is not set")
+	}
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("SELENE_EXEC_TOKEN", "secret")
+	command := []string{"go", "test", "-C", dir, "-count=1", "."}
+
+	var out bytes.Buffer
+	tc := toolchain{goBin: "go"}
+	if _, err := runCommand(tc, command, &out, &out); err != nil {
+		t.Fatalf("runCommand() with the host environment: %s\n%s", err, out.String())
+	}
+
+	out.Reset()
+	tc.environment = &environment{Deny: []string{"SELENE_EXEC_TOKEN"}}
+	if _, err := runCommand(tc, command, &out, &out); err == nil {
+		t.Errorf("runCommand() with SELENE_EXEC_TOKEN denied passed, want the test to fail\n%s", out.String())
+	}
+}
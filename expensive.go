@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expensiveTests are the tests too slow to run for every mutant, such as
+// integration tests, only run in deep mode on the mutants the others let
+// survive.
+type expensiveTests struct {
+	tags  []string // build tags of their files
+	tests string   // go test -run pattern of their names
+}
+
+// newExpensiveTests validates the expensive section of the config, and
+// returns nil if it's empty.
+func newExpensiveTests(tags, tests []string) (*expensiveTests, error) {
+	if len(tags) == 0 && len(tests) == 0 {
+		return nil, nil
+	}
+
+	_, err := compilePatterns("expensive.tests", tests)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if tag == "" || strings.ContainsAny(tag, ", \t") {
+			return nil, &ConfigError{Err: fmt.Errorf("invalid expensive.tags entry %q", tag)}
+		}
+	}
+
+	return &expensiveTests{tags: tags, tests: strings.Join(tests, "|")}, nil
+}
+
+// skipFlags returns the go test flags keeping the expensive tests out of
+// the regular runs. Files with their build tags are left out anyway.
+func (e *expensiveTests) skipFlags() []string {
+	if e == nil || e.tests == "" {
+		return nil
+	}
+	return []string{"-skip", e.tests}
+}
+
+// runFlags returns the go test flags running the expensive tests. With
+// build tags every test runs, as the names of those in tagged files
+// aren't known.
+func (e *expensiveTests) runFlags() []string {
+	if len(e.tags) > 0 {
+		return []string{"-tags", strings.Join(e.tags, ",")}
+	}
+	return []string{"-run", e.tests}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"go/build"
+	"go/types"
+)
+
+// exportedFuncs returns the functions of targets that are part of the API
+// of the package in dir: exported functions, and exported methods of
+// exported types. With helpers, the unexported functions they call
+// directly are returned too, as long as they are in targets. A nil
+// targets stands for all the functions of the package. If strict is set,
+// type checking errors fail instead of leaving helpers out.
+func exportedFuncs(ctx *build.Context, dir string, targets funcSet, helpers, strict bool) (funcSet, error) {
+	graph, err := buildCallGraph(ctx, dir, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	targeted := func(node *callNode) bool {
+		return targets == nil || targets[node.filename][node.line]
+	}
+
+	kept := funcSet{}
+	for fn, node := range graph {
+		if !exported(fn) || !targeted(node) {
+			continue
+		}
+
+		kept.add(node.filename, node.line)
+		if !helpers {
+			continue
+		}
+		for _, callee := range node.callees {
+			if n := graph[callee]; !exported(callee) && targeted(n) {
+				kept.add(n.filename, n.line)
+			}
+		}
+	}
+
+	return kept, nil
+}
+
+// exported reports whether fn can be called from other packages: an
+// exported function, or an exported method of an exported type.
+func exported(fn *types.Func) bool {
+	if !fn.Exported() {
+		return false
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return true
+	}
+
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	return ok && named.Obj().Exported()
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// gerritRobot is the robot ID of the comments posted by selene.
+const gerritRobot = "selene"
+
+// gerritCommand runs the selene gerrit subcommands. The only one so far is
+// review.
+func gerritCommand(args []string) error {
+	usage := &ConfigError{Err: fmt.Errorf("usage: selene gerrit review [--url url] [--change n] [--revision id] [--since HEAD~1] [--label name] [--min-score 80] [--dry-run] <report.json>")}
+	if len(args) == 0 || args[0] != "review" {
+		return usage
+	}
+
+	revisionDefault := os.Getenv("GERRIT_PATCHSET_REVISION")
+	if revisionDefault == "" {
+		revisionDefault = "current"
+	}
+
+	fs := flag.NewFlagSet("gerrit review", flag.ContinueOnError)
+	url := fs.String("url", os.Getenv("GERRIT_URL"), "`URL` of the Gerrit server")
+	change := fs.String("change", os.Getenv("GERRIT_CHANGE_NUMBER"), "`number` or ID of the change to review")
+	revision := fs.String("revision", revisionDefault, "`revision` of the change to review")
+	since := fs.String("since", "HEAD~1", "git `ref` the change is based on, telling which lines it changed")
+	label := fs.String("label", "", "`label` to vote on, none if empty")
+	minScore := fs.Float64("min-score", 80, "mutation score of the changed lines below which the vote is negative")
+	pass := fs.Int("pass", 1, "vote when the score is high enough")
+	fail := fs.Int("fail", -1, "vote when the score is too low")
+	dryRun := fs.Bool("dry-run", false, "print the review instead of posting it")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+	if fs.NArg() != 1 {
+		return usage
+	}
+
+	if !*dryRun {
+		if *url == "" || *change == "" {
+			return &ConfigError{Err: fmt.Errorf("--url or GERRIT_URL, and --change or GERRIT_CHANGE_NUMBER, are required")}
+		}
+		if os.Getenv("GERRIT_USER") == "" || os.Getenv("GERRIT_PASSWORD") == "" {
+			return &ConfigError{Err: fmt.Errorf("GERRIT_USER and GERRIT_PASSWORD are required")}
+		}
+	}
+
+	doc, err := report.ReadDocument(fs.Arg(0))
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	root := git("rev-parse", "--show-toplevel")
+	if root == "" {
+		return &ConfigError{Err: fmt.Errorf("selene gerrit review needs a git repository")}
+	}
+
+	mutants, err := changedMutants(doc, *since)
+	if err != nil {
+		return err
+	}
+
+	review := gerritReview(mutants, root, doc.Metadata.Start)
+	if *label != "" {
+		vote := *pass
+		if score, ok := changedScore(mutants); ok && score < *minScore {
+			vote = *fail
+		}
+		review.Labels = map[string]int{*label: vote}
+	}
+
+	if *dryRun {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(review)
+	}
+
+	g := &gerrit{url: strings.TrimSuffix(*url, "/"), user: os.Getenv("GERRIT_USER"), password: os.Getenv("GERRIT_PASSWORD")}
+	err = g.review(*change, *revision, review)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("reviewed change %s: %s\n", *change, review.Message)
+	return nil
+}
+
+// changedMutants returns the tested mutants of the report on lines added
+// or modified since a ref, in report order.
+func changedMutants(doc report.Document, since string) ([]report.Mutant, error) {
+	type fileLines struct {
+		lines map[int]bool
+		all   bool
+	}
+	files := map[string]fileLines{}
+
+	var changed []report.Mutant
+	for _, r := range doc.Results {
+		for _, m := range r.Mutants {
+			if m.Status != report.Killed && m.Status != report.Survived {
+				continue
+			}
+
+			fl, ok := files[m.File]
+			if !ok {
+				lines, all, err := newLines(m.File, since)
+				if err != nil {
+					return nil, err
+				}
+				fl = fileLines{lines: lines, all: all}
+				files[m.File] = fl
+			}
+
+			if fl.all || fl.lines[m.Line] {
+				changed = append(changed, m)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// changedScore returns the mutation score of the mutants of the changed
+// lines, and false if there are none.
+func changedScore(mutants []report.Mutant) (float64, bool) {
+	if len(mutants) == 0 {
+		return 0, false
+	}
+
+	killed := 0
+	for _, m := range mutants {
+		if m.Status == report.Killed {
+			killed++
+		}
+	}
+	return float64(killed) / float64(len(mutants)) * 100, true
+}
+
+// reviewInput is the review posted to a revision of a Gerrit change.
+type reviewInput struct {
+	Message       string                         `json:"message"`
+	Tag           string                         `json:"tag"`
+	Labels        map[string]int                 `json:"labels,omitempty"`
+	RobotComments map[string][]robotCommentInput `json:"robot_comments,omitempty"`
+}
+
+// robotCommentInput is a comment of a review made by a tool.
+type robotCommentInput struct {
+	RobotID    string `json:"robot_id"`
+	RobotRunID string `json:"robot_run_id"`
+	Line       int    `json:"line"`
+	Message    string `json:"message"`
+}
+
+// gerritReview returns the review of the mutants of the changed lines: a
+// summary of their score, and a comment on the line of every survivor,
+// with paths relative to the repository root.
+func gerritReview(mutants []report.Mutant, root string, start time.Time) reviewInput {
+	review := reviewInput{Tag: "autogenerated:selene"}
+
+	score, ok := changedScore(mutants)
+	if !ok {
+		review.Message = "selene: no mutants on the changed lines"
+		return review
+	}
+
+	survived := 0
+	for _, m := range mutants {
+		if m.Status != report.Survived {
+			continue
+		}
+		survived++
+
+		rel, err := filepath.Rel(root, m.File)
+		if err != nil {
+			rel = m.File
+		}
+		rel = filepath.ToSlash(rel)
+
+		msg := fmt.Sprintf("Mutant %s survived: no test notices this change.", m.ID)
+		if change := m.Change(); change != "" {
+			msg += "\n\n" + change
+		}
+		if m.Repro != "" {
+			msg += "\n\nReproduce with:\n    " + m.Repro
+		}
+
+		if review.RobotComments == nil {
+			review.RobotComments = map[string][]robotCommentInput{}
+		}
+		review.RobotComments[rel] = append(review.RobotComments[rel], robotCommentInput{
+			RobotID:    gerritRobot,
+			RobotRunID: start.UTC().Format(time.RFC3339),
+			Line:       m.Line,
+			Message:    msg,
+		})
+	}
+
+	for _, comments := range review.RobotComments {
+		sort.SliceStable(comments, func(i, j int) bool {
+			return comments[i].Line < comments[j].Line
+		})
+	}
+
+	review.Message = fmt.Sprintf("selene: %d mutants on the changed lines, %d survived, mutation score %.1f%%", len(mutants), survived, score)
+	return review
+}
+
+// gerrit is a minimal client of the Gerrit REST API, authenticated with
+// an HTTP password.
+type gerrit struct {
+	url      string
+	user     string
+	password string
+}
+
+// review posts a review to a revision of a change.
+func (g *gerrit) review(change, revision string, review reviewInput) error {
+	b, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("/a/changes/%s/revisions/%s/review", change, revision)
+	req, err := http.NewRequest(http.MethodPost, g.url+endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(g.user, g.password)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Gerrit POST %s returned %s: %s", endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// heatmap renders a treemap of the files of a run, sized by mutants and
+// colored by how many of them survived, to show at a glance where the
+// tests are weakest.
+func heatmap(args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	output := fs.String("o", "heatmap.html", "HTML `file` to write")
+	width := fs.Int("width", 1200, "width of the treemap in pixels")
+	height := fs.Int("height", 800, "height of the treemap in pixels")
+	err := fs.Parse(args)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if fs.NArg() != 1 {
+		return &ConfigError{Err: fmt.Errorf("usage: selene heatmap [-o heatmap.html] [-width 1200] [-height 800] <report.json>")}
+	}
+	if *width <= 0 || *height <= 0 {
+		return &ConfigError{Err: fmt.Errorf("the treemap needs a positive width and height")}
+	}
+
+	doc, err := report.ReadDocument(fs.Arg(0))
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = report.RenderHeatmap(f, doc, float64(*width), float64(*height))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("heatmap of %d packages written to %s\n", len(doc.Results), *output)
+	return f.Close()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// pairs pairs the mutants of each file at random into second-order
+// mutants, each first-order one in at most one pair. Mutants at the same
+// position change the same code, so they are never paired. Pairs are
+// sorted by their first mutant, in the order mutants were found.
+func pairs(mutants []mutant, rng *rand.Rand) [][2]int {
+	byFile := map[string][]int{}
+	var files []string
+	for i, mt := range mutants {
+		if byFile[mt.File] == nil {
+			files = append(files, mt.File)
+		}
+		byFile[mt.File] = append(byFile[mt.File], i)
+	}
+
+	var result [][2]int
+	for _, file := range files {
+		indexes := byFile[file]
+		rng.Shuffle(len(indexes), func(i, j int) {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		})
+
+		var unpaired []int
+		for _, i := range indexes {
+			j := slices.IndexFunc(unpaired, func(j int) bool {
+				return mutants[j].Pos.Offset != mutants[i].Pos.Offset
+			})
+			if j < 0 {
+				unpaired = append(unpaired, i)
+				continue
+			}
+			result = append(result, [2]int{min(i, unpaired[j]), max(i, unpaired[j])})
+			unpaired = slices.Delete(unpaired, j, j+1)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i][0] < result[j][0]
+	})
+	return result
+}
+
+// runHigherOrder tests second-order mutants, pairs of the first-order
+// mutants of the package in pkgDir applied together, and adds them to the
+// higher-order results. They are experimental: pairs are drawn at random
+// for each run, unless --seed repeats those of an earlier one, only the
+// regular tests run, and they aren't scored.
+// Pairs that can't be applied together are left out.
+func (r *runner) runHigherOrder(pkgDir, mutationDir string, mutants []mutant, result *report.Result) error {
+	workers, concurrencyFlags := r.concurrency(pkgDir)
+	testFlags := append(r.testFlags(), concurrencyFlags...)
+	testFlags = append(testFlags, r.timeoutFlags(pkgDir)...)
+	testFlags = append(testFlags, result.TestedBy...)
+
+	log.Printf("%s: pairing higher-order mutants with seed %d", pkgDir, r.seed)
+	pairs := pairs(mutants, rand.New(rand.NewSource(r.seed)))
+
+	done := make([]*report.Mutant, len(pairs))
+	errs := make([]error, len(pairs))
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		wg.Add(1)
+		go func(i int, a, b mutant) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			dir := filepath.Join(mutationDir, "order2-"+strconv.Itoa(i+1))
+			done[i], errs[i] = r.runPair(pkgDir, a, b, dir, testFlags)
+		}(i, mutants[pair[0]], mutants[pair[1]])
+	}
+	wg.Wait()
+
+	for i, pair := range pairs {
+		if errs[i] != nil {
+			return fmt.Errorf("mutant %s+%s: %s", mutants[pair[0]].ID, mutants[pair[1]].ID, errs[i])
+		}
+		if done[i] != nil {
+			result.HigherOrder = append(result.HigherOrder, *done[i])
+		}
+	}
+	return nil
+}
+
+// runPair runs the package tests with mutants a and b applied, with its
+// files in dir, and returns nil if they can't be applied together.
+func (r *runner) runPair(pkgDir string, a, b mutant, dir string, testFlags []string) (*report.Mutant, error) {
+	result := report.Mutant{
+		ID:      a.ID + "+" + b.ID,
+		File:    a.File,
+		Line:    a.Pos.Line,
+		Column:  a.Pos.Column,
+		Mutator: a.Mutator.Name + "+" + b.Mutator.Name,
+		Version: a.Mutator.Version + "+" + b.Mutator.Version,
+		Log:     filepath.Join(dir, "gotest.log.gz"),
+		Overlay: filepath.Join(dir, "overlay.json"),
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced, _, err := writeMutants(r.opts.toolchain.buildContext(), []mutant{a, b}, dir)
+	if err != nil {
+		log.Printf("mutant %s: %s, leaving it out", result.ID, err)
+		return nil, nil
+	}
+
+	merged, err := mergeOverlays(r.userOverlay, replaced)
+	if err != nil {
+		return nil, err
+	}
+	_, err = writeOverlay(result.Overlay, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("running go test for mutant %s", result.ID)
+
+	tests, err := r.opts.toolchain.runGoTest(pkgDir, result.Overlay, result.Log, testFlags)
+	if err != nil {
+		return nil, fmt.Errorf("error running go test: %s", err)
+	}
+
+	result.GoTest = r.opts.toolchain.testCommand(pkgDir, result.Overlay, testFlags)
+	result.Status, result.KilledBy = verdict(tests)
+	if result.Status == report.Killed {
+		result.Cause = killCause(tests)
+	}
+	for _, test := range tests {
+		if test.Test == "" && (test.Action == "pass" || test.Action == "fail") {
+			result.Elapsed = test.Elapsed
+		}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// history remembers which tests killed mutants of each source file in
+// previous runs, so the tests most likely to kill a new mutant run first,
+// and since when mutants have been surviving.
+type history struct {
+	mu    sync.Mutex
+	Kills map[string]map[string]int `json:"kills"` // file, test, mutants killed
+
+	// Survivors are the mutants that survived the last time they ran, by
+	// ID and mutator version, so a new version starts afresh.
+	Survivors map[string]survivor `json:"survivors,omitempty"`
+}
+
+// survivor is when a mutant was first seen surviving.
+type survivor struct {
+	Since  time.Time `json:"since"`
+	Commit string    `json:"commit,omitempty"`
+}
+
+// loadHistory reads the history file, which may not exist yet.
+func loadHistory(filename string) (*history, error) {
+	h := &history{Kills: map[string]map[string]int{}, Survivors: map[string]survivor{}}
+
+	bytes, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("failed to read history: %s", err)}
+	}
+
+	err = json.Unmarshal(bytes, h)
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("invalid history %s: %s", filename, err)}
+	}
+	if h.Kills == nil {
+		h.Kills = map[string]map[string]int{}
+	}
+	if h.Survivors == nil {
+		h.Survivors = map[string]survivor{}
+	}
+
+	return h, nil
+}
+
+func (h *history) save(filename string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bytes, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, bytes, 0o644)
+}
+
+// record counts a kill for the top level tests that failed. Subtests, as
+// the cases of table-driven tests or the methods of testify suites, are
+// counted with the test function running them, as -run can only select
+// them through it.
+func (h *history) record(file string, killedBy []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counted := map[string]bool{}
+	for _, test := range killedBy {
+		test, _, _ = strings.Cut(test, "/")
+		if counted[test] {
+			continue
+		}
+		counted[test] = true
+
+		if h.Kills[file] == nil {
+			h.Kills[file] = map[string]int{}
+		}
+		h.Kills[file][test]++
+	}
+}
+
+// survived records that the mutant with the given key survived, and
+// returns when it was first seen surviving: now, unless it already
+// survived the previous time it ran.
+func (h *history) survived(key string, now time.Time, commit string) survivor {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.Survivors[key]
+	if !ok {
+		s = survivor{Since: now, Commit: commit}
+		h.Survivors[key] = s
+	}
+	return s
+}
+
+// forget records that the mutant with the given key didn't survive.
+func (h *history) forget(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.Survivors, key)
+}
+
+// killers returns a go test -run pattern matching the tests that killed
+// mutants of file before, or an empty string if there are none.
+func (h *history) killers(file string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var tests []string
+	for test := range h.Kills[file] {
+		tests = append(tests, regexp.QuoteMeta(test))
+	}
+	if len(tests) == 0 {
+		return ""
+	}
+
+	sort.Strings(tests)
+	return "^(" + strings.Join(tests, "|") + ")$"
+}
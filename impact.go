@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// funcSet identifies functions by file and the line of their name. It is
+// how the functions to mutate are passed to mutateFile, which parses the
+// files again.
+type funcSet map[string]map[int]bool
+
+func (s funcSet) add(filename string, line int) {
+	if s[filename] == nil {
+		s[filename] = map[int]bool{}
+	}
+	s[filename][line] = true
+}
+
+// changedLines returns the lines added or modified since ref, by absolute
+// file name. Lines removed are attributed to the line that follows them.
+func changedLines(dir, ref string) (map[string]map[int]bool, error) {
+	top, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %s", dir)
+	}
+	root := strings.TrimSpace(string(top))
+
+	out, err := exec.Command("git", "-C", dir, "diff", "--unified=0", "--no-color", "--no-ext-diff", ref, "--", ".").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s failed: %s", ref, err)
+	}
+
+	lines := map[string]map[int]bool{}
+	var current string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = ""
+			name, ok := strings.CutPrefix(line, "+++ b/")
+			if ok {
+				current = filepath.Join(root, name)
+				lines[current] = map[int]bool{}
+			}
+		case strings.HasPrefix(line, "@@ ") && current != "":
+			// @@ -start,count +start,count @@
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+
+			start, count, err := parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+			if err != nil {
+				return nil, fmt.Errorf("bad hunk header %q: %s", line, err)
+			}
+
+			if count == 0 {
+				// pure deletion, after line start
+				lines[current][start+1] = true
+			}
+			for i := start; i < start+count; i++ {
+				lines[current][i] = true
+			}
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseHunkRange(s string) (int, int, error) {
+	startStr, countStr, found := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !found {
+		return start, 1, nil
+	}
+
+	count, err := strconv.Atoi(countStr)
+	return start, count, err
+}
+
+// impactedFuncs returns the functions of the package in dir changed since
+// ref, plus the functions of the same package that call them or are called
+// by them, up to depth calls away. If strict is set, type checking errors
+// fail instead of leaving the call graph incomplete.
+func impactedFuncs(ctx *build.Context, dir, ref string, depth int, strict bool) (funcSet, error) {
+	changed, err := changedLines(dir, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := buildCallGraph(ctx, dir, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	impacted := map[*types.Func]bool{}
+	var frontier []*types.Func
+	for fn, node := range graph {
+		for line := node.start; line <= node.end; line++ {
+			if changed[node.filename][line] {
+				impacted[fn] = true
+				frontier = append(frontier, fn)
+				break
+			}
+		}
+	}
+
+	for i := 0; i < depth && len(frontier) > 0; i++ {
+		var next []*types.Func
+		for _, fn := range frontier {
+			node := graph[fn]
+			for _, neighbours := range [][]*types.Func{node.callees, node.callers} {
+				for _, n := range neighbours {
+					if !impacted[n] {
+						impacted[n] = true
+						next = append(next, n)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+
+	set := funcSet{}
+	for fn := range impacted {
+		node := graph[fn]
+		set.add(node.filename, node.line)
+	}
+
+	return set, nil
+}
+
+// callNode is a function declared in the package being mutated.
+type callNode struct {
+	filename   string
+	line       int // line of the function name
+	start, end int // lines of the whole declaration
+	callees    []*types.Func
+	callers    []*types.Func
+}
+
+// buildCallGraph builds the static call graph between the functions
+// declared in the package in dir. Function values count as calls and
+// calls through interfaces are ignored. Type checking errors, such as
+// dependencies that can't be imported, only make the graph less complete,
+// unless strict is set.
+func buildCallGraph(ctx *build.Context, dir string, strict bool) (map[*types.Func]*callNode, error) {
+	bpkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range append(bpkg.GoFiles, bpkg.CgoFiles...) {
+		file, err := parser.ParseFile(fset, filepath.Join(bpkg.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{
+		Defs: map[*ast.Ident]types.Object{},
+		Uses: map[*ast.Ident]types.Object{},
+	}
+	var typeErr error
+	conf := types.Config{
+		Importer:    sharedImporter(ctx),
+		FakeImportC: true,
+		Error: func(err error) {
+			if typeErr == nil {
+				typeErr = err
+			}
+		},
+	}
+	conf.Check(bpkg.ImportPath, fset, files, info)
+
+	if strict && typeErr != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("strict: call graph incomplete: %s", typeErr)}
+	}
+
+	graph := map[*types.Func]*callNode{}
+	var decls []*ast.FuncDecl
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+
+			fn, ok := info.Defs[fd.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+
+			name := fset.Position(fd.Name.Pos())
+			graph[fn] = &callNode{
+				filename: name.Filename,
+				line:     name.Line,
+				start:    fset.Position(fd.Pos()).Line,
+				end:      fset.Position(fd.End()).Line,
+			}
+			decls = append(decls, fd)
+		}
+	}
+
+	for _, fd := range decls {
+		caller := info.Defs[fd.Name].(*types.Func)
+		seen := map[*types.Func]bool{}
+		ast.Inspect(fd.Body, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+
+			callee, ok := info.Uses[id].(*types.Func)
+			if !ok {
+				return true
+			}
+			// methods of generic types are used through their instances
+			callee = callee.Origin()
+
+			node, ok := graph[callee]
+			if ok && callee != caller && !seen[callee] {
+				seen[callee] = true
+				graph[caller].callees = append(graph[caller].callees, callee)
+				node.callers = append(node.callers, caller)
+			}
+			return true
+		})
+	}
+
+	return graph, nil
+}
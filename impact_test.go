@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestParseHunkRange(t *testing.T) {
+	tests := []struct {
+		in           string
+		start, count int
+		wantErr      bool
+	}{
+		{"12", 12, 1, false},
+		{"12,3", 12, 3, false},
+		{"12,0", 12, 0, false},
+		{"x", 0, 0, true},
+		{"12,x", 12, 0, true},
+	}
+
+	for _, tt := range tests {
+		start, count, err := parseHunkRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseHunkRange(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (start != tt.start || count != tt.count) {
+			t.Errorf("parseHunkRange(%q) = %d, %d, want %d, %d", tt.in, start, count, tt.start, tt.count)
+		}
+	}
+}
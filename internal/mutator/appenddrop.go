@@ -0,0 +1,71 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "AppendDrop",
+		Version:     "1.1.0",
+		Types:       true,
+		Packs:       []string{"data"},
+		Description: "Drops the appended elements of append calls, returning the elements of the slice only, exposing tests that check for errors but not the contents of the collections built. The elements are still appended, to a copy cut back to the length of the slice, so they are evaluated and used as before. Only appends to variables, fields and index expressions are mutated, as the slice is evaluated more than once.",
+		Before:      "found = append(found, name)",
+		After:       "found = append(found[:len(found):len(found)], name)[:len(found)]",
+		Mutations:   appendDrop,
+	})
+}
+
+func appendDrop(c *astutil.Cursor, info *types.Info) []Mutation {
+	call, ok := c.Node().(*ast.CallExpr)
+	if !ok || len(call.Args) < 2 {
+		return nil
+	}
+
+	// append may be shadowed, only the builtin is mutated
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if builtin, ok := info.Uses[ident].(*types.Builtin); !ok || builtin.Name() != "append" {
+		return nil
+	}
+
+	// the slice is evaluated three times
+	s := call.Args[0]
+	if !repeatable(s) {
+		return nil
+	}
+
+	return mutations(func() {
+		length := func() ast.Expr {
+			return &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{s}}
+		}
+		// capped, so the elements are appended to a copy
+		call.Args[0] = &ast.SliceExpr{X: s, High: length(), Max: length(), Slice3: true}
+		c.Replace(&ast.SliceExpr{X: call, High: length()})
+	})
+}
+
+// repeatable reports whether expr can be evaluated more than once with
+// the same result: a variable, a field, or an index expression of those,
+// indexed by a variable or a constant.
+func repeatable(expr ast.Expr) bool {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.BasicLit:
+		return true
+	case *ast.SelectorExpr:
+		return repeatable(x.X)
+	case *ast.IndexExpr:
+		return repeatable(x.X) && repeatable(x.Index)
+	case *ast.ParenExpr:
+		return repeatable(x.X)
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "BlockingSelect",
+		Version:     "1.0.0",
+		OptIn:       true,
+		Slow:        true,
+		Packs:       []string{"concurrency"},
+		Description: "Deletes the default clause of select statements, making non-blocking sends and receives block. Tests that never take the non-blocking path let it survive, those that do hang until the go test timeout kills it, which makes killed mutants slow. Selects with only a default clause are left alone.",
+		Before: `select {
+case events <- e:
+default:
+	dropped++
+}`,
+		After: `select {
+case events <- e:
+}`,
+		Mutations: blockingSelect,
+	})
+}
+
+func blockingSelect(c *astutil.Cursor, _ *types.Info) []Mutation {
+	stmt, ok := c.Node().(*ast.SelectStmt)
+	if !ok || len(stmt.Body.List) < 2 {
+		return nil
+	}
+
+	for i, clause := range stmt.Body.List {
+		if clause.(*ast.CommClause).Comm != nil {
+			continue
+		}
+
+		// selects without a break are terminating with or without
+		// their default clause, so the function still compiles
+		return []Mutation{{Pos: clause.Pos(), Apply: func() {
+			stmt.Body.List = append(stmt.Body.List[:i:i], stmt.Body.List[i+1:]...)
+		}}}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "BooleanReturn",
+		Version:     "1.0.0",
+		Types:       true,
+		Packs:       []string{"logic"},
+		Description: "Flips returned true and false, and negates other boolean return values.",
+		Before:      "return n%2 == 0",
+		After:       "return !(n%2 == 0)",
+		Mutations:   booleanReturn,
+	})
+}
+
+func booleanReturn(c *astutil.Cursor, info *types.Info) []Mutation {
+	ret, ok := c.Node().(*ast.ReturnStmt)
+	if !ok {
+		return nil
+	}
+
+	var ms []Mutation
+	for i, result := range ret.Results {
+		i := i
+		tv, ok := info.Types[result]
+		if !ok || !isBool(tv.Type) {
+			continue
+		}
+
+		flipped := negate(result)
+		ms = append(ms, Mutation{Pos: result.Pos(), Apply: func() {
+			ret.Results[i] = flipped
+		}})
+	}
+
+	return ms
+}
+
+// negate returns the negation of a boolean expression, as simple as the
+// expression allows.
+func negate(expr ast.Expr) ast.Expr {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		switch x.Name {
+		case "true":
+			return ast.NewIdent("false")
+		case "false":
+			return ast.NewIdent("true")
+		}
+		return &ast.UnaryExpr{Op: token.NOT, X: x}
+	case *ast.UnaryExpr:
+		if x.Op == token.NOT {
+			return x.X
+		}
+	case *ast.CallExpr, *ast.SelectorExpr, *ast.ParenExpr:
+		return &ast.UnaryExpr{Op: token.NOT, X: x}
+	}
+	return &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: expr}}
+}
+
+func isBool(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsBoolean != 0
+}
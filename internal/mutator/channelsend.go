@@ -0,0 +1,36 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "ChannelSend",
+		Version:     "1.0.0",
+		OptIn:       true,
+		Slow:        true,
+		Packs:       []string{"concurrency"},
+		Description: "Removes channel send statements, exposing producer and consumer tests that never check what was received. Consumers waiting for the value hang until the go test timeout kills the mutant, which makes killed mutants slow. Sends in select cases are left alone.",
+		Before:      "results <- r",
+		After:       "// removed",
+		Mutations:   channelSend,
+	})
+}
+
+func channelSend(c *astutil.Cursor, _ *types.Info) []Mutation {
+	// statements can only be removed from a list, which the sends of
+	// select cases aren't in
+	if c.Index() < 0 {
+		return nil
+	}
+
+	if _, ok := c.Node().(*ast.SendStmt); !ok {
+		return nil
+	}
+
+	return mutations(c.Delete)
+}
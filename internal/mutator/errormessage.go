@@ -0,0 +1,53 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "ErrorMessage",
+		Version:     "1.0.0",
+		OptIn:       true,
+		Packs:       []string{"errors"},
+		Description: `Prefixes the message of errors.New and fmt.Errorf calls with "MUTANT: ". Only tests asserting the exact error text can kill these mutants, which many consider low value.`,
+		Before:      `errors.New("not found")`,
+		After:       `errors.New("MUTANT: not found")`,
+		Mutations:   errorMessage,
+	})
+}
+
+func errorMessage(c *astutil.Cursor, _ *types.Info) []Mutation {
+	call, ok := c.Node().(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || !(pkg.Name == "errors" && sel.Sel.Name == "New" || pkg.Name == "fmt" && sel.Sel.Name == "Errorf") {
+		return nil
+	}
+
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil
+	}
+
+	msg, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil
+	}
+
+	return mutations(func() {
+		lit.Value = quoteLike(lit.Value, "MUTANT: "+msg)
+	})
+}
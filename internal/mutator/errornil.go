@@ -0,0 +1,49 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "ErrorNil",
+		Version:     "1.0.0",
+		Types:       true,
+		Packs:       []string{"errors"},
+		Description: "Replaces returned errors with nil, exposing tests that never check the errors a function returns.",
+		Before:      "return nil, err",
+		After:       "return nil, nil",
+		Mutations:   errorNil,
+	})
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func errorNil(c *astutil.Cursor, info *types.Info) []Mutation {
+	ret, ok := c.Node().(*ast.ReturnStmt)
+	if !ok {
+		return nil
+	}
+
+	var ms []Mutation
+	for i, result := range ret.Results {
+		i := i
+		if id, ok := result.(*ast.Ident); ok && id.Name == "nil" {
+			continue
+		}
+
+		tv, ok := info.Types[result]
+		if !ok || !types.Identical(tv.Type, errorType) {
+			continue
+		}
+
+		ms = append(ms, Mutation{Pos: result.Pos(), Apply: func() {
+			ret.Results[i] = ast.NewIdent("nil")
+		}})
+	}
+
+	return ms
+}
@@ -0,0 +1,81 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "Float",
+		Version:     "1.0.0",
+		Types:       true,
+		Packs:       []string{"numeric"},
+		Description: "Replaces floating-point division with multiplication, adds a small epsilon to floating-point arithmetic and removes calls to math.Abs.",
+		Before:      "ratio := done / total",
+		After:       "ratio := done * total",
+		Mutations:   float,
+	})
+}
+
+// epsilon is small enough to survive rounding in most code, but not in
+// exact comparisons.
+const epsilon = "1e-9"
+
+func float(c *astutil.Cursor, info *types.Info) []Mutation {
+	switch x := c.Node().(type) {
+	case *ast.BinaryExpr:
+		switch x.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO:
+		default:
+			return nil
+		}
+
+		// constant expressions are left alone, as they may be required
+		// to be constant
+		tv, ok := info.Types[x]
+		if !ok || tv.Value != nil || !isFloat(tv.Type) {
+			return nil
+		}
+
+		var apply []func()
+		if x.Op == token.QUO {
+			apply = append(apply, func() {
+				x.Op = token.MUL
+			})
+		}
+		apply = append(apply, func() {
+			c.Replace(&ast.BinaryExpr{
+				X:  &ast.ParenExpr{X: x},
+				Op: token.ADD,
+				Y:  &ast.BasicLit{Kind: token.FLOAT, Value: epsilon},
+			})
+		})
+		return mutations(apply...)
+
+	case *ast.CallExpr:
+		sel, ok := x.Fun.(*ast.SelectorExpr)
+		if !ok || len(x.Args) != 1 {
+			return nil
+		}
+
+		fn, ok := info.Uses[sel.Sel].(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "math" || fn.Name() != "Abs" {
+			return nil
+		}
+
+		return mutations(func() {
+			c.Replace(x.Args[0])
+		})
+	}
+
+	return nil
+}
+
+func isFloat(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsFloat != 0
+}
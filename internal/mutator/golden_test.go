@@ -0,0 +1,224 @@
+package mutator
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the mutators")
+
+// fset and imports are shared by the examples, so the packages they
+// import are only type checked once.
+var (
+	fset    = token.NewFileSet()
+	imports = importer.ForCompiler(fset, "source", nil)
+)
+
+// TestGolden applies each mutation of every mutator on its own to the
+// code of testdata/<name>.go, as selene applies mutants, and compares the
+// lines changed with testdata/<name>.golden. Every mutated example must
+// type check, as mutants that don't build test nothing. Run with -update
+// to rewrite the golden files after changing a mutator, and bump its
+// version.
+func TestGolden(t *testing.T) {
+	for _, m := range All() {
+		m := m
+		t.Run(m.Name, func(t *testing.T) {
+			filename := filepath.Join("testdata", strings.ToLower(m.Name)+".go")
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("mutators need an example in testdata: %s", err)
+			}
+
+			var got bytes.Buffer
+			for n := 0; ; n++ {
+				pos, mutated, ok := mutate(t, m, filename, src, n)
+				if !ok {
+					break
+				}
+				fmt.Fprintf(&got, "-- %d:%d --\n", pos.Line, pos.Column)
+				writeChange(&got, src, mutated)
+			}
+
+			golden := strings.TrimSuffix(filename, ".go") + ".golden"
+			if *update {
+				err := os.WriteFile(golden, got.Bytes(), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%s, run go test -update to create it", err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("mutations of %s differ from %s:\n%s", filename, golden, got.String())
+			}
+		})
+	}
+}
+
+// TestExamples checks the documented example of every mutator against
+// its code in testdata: the example before the mutation is in the code,
+// and after it in the code with one of the mutations applied, unless the
+// mutation removes code.
+func TestExamples(t *testing.T) {
+	for _, m := range All() {
+		filename := filepath.Join("testdata", strings.ToLower(m.Name)+".go")
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			t.Errorf("%s: %s", m.Name, err)
+			continue
+		}
+
+		if !strings.Contains(collapse(string(src)), collapse(m.Before)) {
+			t.Errorf("%s: the example %q isn't in %s", m.Name, m.Before, filename)
+		}
+		if m.After == "// removed" {
+			continue
+		}
+
+		found := false
+		for n := 0; !found; n++ {
+			_, mutated, ok := mutate(t, m, filename, src, n)
+			if !ok {
+				break
+			}
+			found = strings.Contains(collapse(string(mutated)), collapse(m.After))
+		}
+		if !found {
+			t.Errorf("%s: no mutation of %s gives the example %q", m.Name, filename, m.After)
+		}
+	}
+}
+
+// mutate returns the source of filename with the n-th mutation of m
+// applied, counting the mutations of every node in walk order, and where
+// it is made. It returns false if m makes fewer mutations.
+func mutate(t *testing.T, m Mutator, filename string, src []byte, n int) (token.Position, []byte, bool) {
+	t.Helper()
+	index := n
+
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info *types.Info
+	if m.Types {
+		info = &types.Info{
+			Types: map[ast.Expr]types.TypeAndValue{},
+			Defs:  map[*ast.Ident]types.Object{},
+			Uses:  map[*ast.Ident]types.Object{},
+		}
+		conf := types.Config{Importer: imports}
+		_, err := conf.Check("example", fset, []*ast.File{file}, info)
+		if err != nil {
+			t.Fatalf("the example of %s must type check: %s", m.Name, err)
+		}
+	}
+
+	var pos token.Pos
+	applied := false
+	astutil.Apply(file, nil, func(c *astutil.Cursor) bool {
+		if applied {
+			return true
+		}
+
+		variants := m.Mutations(c, info)
+		if n >= len(variants) {
+			n -= len(variants)
+			return true
+		}
+
+		mutation := variants[n]
+		pos = mutation.Pos
+		if !pos.IsValid() {
+			pos = c.Node().Pos()
+		}
+		mutation.Apply()
+		for _, edit := range mutation.Linked {
+			edit.Apply()
+		}
+		applied = true
+		return true
+	})
+	if !applied {
+		return token.Position{}, nil, false
+	}
+
+	var buf bytes.Buffer
+	err = format.Node(&buf, fset, file)
+	if err != nil {
+		t.Fatalf("mutation %d at %s can't be printed: %s", n, fset.Position(pos), err)
+	}
+	typeCheck(t, m, index, fset.Position(pos), buf.Bytes())
+	return fset.Position(pos), buf.Bytes(), true
+}
+
+// typeCheck fails the test if the mutated source doesn't type check, once
+// the imports it no longer uses are removed, as selene does when writing
+// mutants; a mutant that doesn't build never tests anything.
+func typeCheck(t *testing.T, m Mutator, n int, pos token.Position, src []byte) {
+	t.Helper()
+
+	file, err := parser.ParseFile(fset, pos.Filename, src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("mutation %d of %s at %s doesn't parse: %s", n, m.Name, pos, err)
+	}
+	for _, spec := range file.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		if !astutil.UsesImport(file, path) {
+			astutil.DeleteImport(fset, file, path)
+		}
+	}
+
+	conf := types.Config{Importer: imports}
+	_, err = conf.Check("example", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Errorf("mutation %d of %s at %s doesn't type check: %s", n, m.Name, pos, err)
+	}
+}
+
+// writeChange writes the lines of b differing from a, the lines removed
+// prefixed with - and those added with +.
+func writeChange(w *bytes.Buffer, a, b []byte) {
+	al := strings.Split(string(a), "\n")
+	bl := strings.Split(string(b), "\n")
+
+	prefix := 0
+	for prefix < len(al) && prefix < len(bl) && al[prefix] == bl[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(al)-prefix && suffix < len(bl)-prefix && al[len(al)-1-suffix] == bl[len(bl)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range al[prefix : len(al)-suffix] {
+		fmt.Fprintf(w, "-%s\n", line)
+	}
+	for _, line := range bl[prefix : len(bl)-suffix] {
+		fmt.Fprintf(w, "+%s\n", line)
+	}
+}
+
+// collapse returns s with its runs of white space as single spaces.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,55 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(goStatementMutator(false))
+}
+
+// goStatementMutator returns the GoStatement mutator, removing the calls of
+// go statements too, not only making them synchronous, if remove is set.
+func goStatementMutator(remove bool) Mutator {
+	return Mutator{
+		Name:        "GoStatement",
+		Version:     "1.0.0",
+		OptIn:       true,
+		Slow:        true,
+		Packs:       []string{"concurrency"},
+		Description: "Calls the functions started by go statements synchronously, exposing tests that never verify what runs concurrently. The calls can be removed too, as set in the config. Goroutines running until told to stop hang until the go test timeout kills the mutant, which makes killed mutants slow.",
+		Before:      "go s.flush(batch)",
+		After:       "s.flush(batch)",
+		Mutations: func(c *astutil.Cursor, _ *types.Info) []Mutation {
+			return goStatement(c, remove)
+		},
+		Settings: func() string {
+			if remove {
+				return "remove"
+			}
+			return ""
+		},
+		Configure: func(cfg Config) Mutator {
+			return goStatementMutator(cfg.GoRemove)
+		},
+	}
+}
+
+func goStatement(c *astutil.Cursor, remove bool) []Mutation {
+	stmt, ok := c.Node().(*ast.GoStmt)
+	if !ok {
+		return nil
+	}
+
+	ms := mutations(func() {
+		c.Replace(&ast.ExprStmt{X: stmt.Call})
+	})
+	// statements can only be removed from a list
+	if remove && c.Index() >= 0 {
+		ms = append(ms, mutations(c.Delete)...)
+	}
+	return ms
+}
@@ -0,0 +1,248 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "HTTPStatus",
+		Version:     "1.1.0",
+		Packs:       []string{"web"},
+		Description: "Replaces net/http status constants, and integer status codes written or compared with a StatusCode, with another status of the same family, and swaps HTTP methods, both the net/http constants and the string literals passed as methods. Values of case clauses are left alone, as the replacement may be another case of the switch.",
+		Before:      `w.WriteHeader(http.StatusCreated)`,
+		After:       `w.WriteHeader(http.StatusOK)`,
+		Mutations:   httpStatus,
+	})
+}
+
+// statusCodes are the net/http status constants.
+var statusCodes = map[string]int{
+	"StatusContinue": 100, "StatusSwitchingProtocols": 101, "StatusProcessing": 102, "StatusEarlyHints": 103,
+	"StatusOK": 200, "StatusCreated": 201, "StatusAccepted": 202, "StatusNonAuthoritativeInfo": 203,
+	"StatusNoContent": 204, "StatusResetContent": 205, "StatusPartialContent": 206, "StatusMultiStatus": 207,
+	"StatusAlreadyReported": 208, "StatusIMUsed": 226,
+	"StatusMultipleChoices": 300, "StatusMovedPermanently": 301, "StatusFound": 302, "StatusSeeOther": 303,
+	"StatusNotModified": 304, "StatusUseProxy": 305, "StatusTemporaryRedirect": 307, "StatusPermanentRedirect": 308,
+	"StatusBadRequest": 400, "StatusUnauthorized": 401, "StatusPaymentRequired": 402, "StatusForbidden": 403,
+	"StatusNotFound": 404, "StatusMethodNotAllowed": 405, "StatusNotAcceptable": 406, "StatusProxyAuthRequired": 407,
+	"StatusRequestTimeout": 408, "StatusConflict": 409, "StatusGone": 410, "StatusLengthRequired": 411,
+	"StatusPreconditionFailed": 412, "StatusRequestEntityTooLarge": 413, "StatusRequestURITooLong": 414,
+	"StatusUnsupportedMediaType": 415, "StatusRequestedRangeNotSatisfiable": 416, "StatusExpectationFailed": 417,
+	"StatusTeapot": 418, "StatusMisdirectedRequest": 421, "StatusUnprocessableEntity": 422, "StatusLocked": 423,
+	"StatusFailedDependency": 424, "StatusTooEarly": 425, "StatusUpgradeRequired": 426, "StatusPreconditionRequired": 428,
+	"StatusTooManyRequests": 429, "StatusRequestHeaderFieldsTooLarge": 431, "StatusUnavailableForLegalReasons": 451,
+	"StatusInternalServerError": 500, "StatusNotImplemented": 501, "StatusBadGateway": 502, "StatusServiceUnavailable": 503,
+	"StatusGatewayTimeout": 504, "StatusHTTPVersionNotSupported": 505, "StatusVariantAlsoNegotiates": 506,
+	"StatusInsufficientStorage": 507, "StatusLoopDetected": 508, "StatusNotExtended": 510,
+	"StatusNetworkAuthenticationRequired": 511,
+}
+
+// statusSwaps are the replacements within each family of statuses, by the
+// first digit of the code: the most common status becomes the second most
+// common one, and any other status the most common one.
+var statusSwaps = map[int][2]string{
+	1: {"StatusContinue", "StatusSwitchingProtocols"},
+	2: {"StatusOK", "StatusNoContent"},
+	3: {"StatusFound", "StatusMovedPermanently"},
+	4: {"StatusBadRequest", "StatusNotFound"},
+	5: {"StatusInternalServerError", "StatusServiceUnavailable"},
+}
+
+// methodSwaps are the replacements of HTTP methods.
+var methodSwaps = map[string]string{
+	"GET":     "POST",
+	"POST":    "GET",
+	"PUT":     "PATCH",
+	"PATCH":   "PUT",
+	"DELETE":  "GET",
+	"HEAD":    "GET",
+	"OPTIONS": "GET",
+}
+
+// methodConstants are the net/http method constants, by method.
+var methodConstants = map[string]string{
+	"GET":     "MethodGet",
+	"POST":    "MethodPost",
+	"PUT":     "MethodPut",
+	"PATCH":   "MethodPatch",
+	"DELETE":  "MethodDelete",
+	"HEAD":    "MethodHead",
+	"OPTIONS": "MethodOptions",
+}
+
+func httpStatus(c *astutil.Cursor, _ *types.Info) []Mutation {
+	if _, ok := c.Parent().(*ast.CaseClause); ok {
+		return nil
+	}
+
+	switch x := c.Node().(type) {
+	case *ast.SelectorExpr:
+		pkg, ok := x.X.(*ast.Ident)
+		if !ok || pkg.Name != "http" {
+			return nil
+		}
+
+		replacement := swapStatus(x.Sel.Name)
+		if replacement == "" {
+			replacement = swapMethodConstant(x.Sel.Name)
+		}
+		if replacement == "" {
+			return nil
+		}
+
+		return mutations(func() {
+			x.Sel = ast.NewIdent(replacement)
+		})
+
+	case *ast.BasicLit:
+		switch x.Kind {
+		case token.STRING:
+			method, err := strconv.Unquote(x.Value)
+			if err != nil {
+				return nil
+			}
+
+			swap, ok := methodSwaps[method]
+			if !ok || !isMethod(c) {
+				return nil
+			}
+
+			return mutations(func() {
+				x.Value = strconv.Quote(swap)
+			})
+
+		case token.INT:
+			code, err := strconv.Atoi(x.Value)
+			if err != nil || !isStatus(c) {
+				return nil
+			}
+
+			swap := ""
+			for name, value := range statusCodes {
+				if value == code {
+					swap = swapStatus(name)
+				}
+			}
+			if swap == "" {
+				return nil
+			}
+
+			return mutations(func() {
+				x.Value = strconv.Itoa(statusCodes[swap])
+			})
+		}
+	}
+
+	return nil
+}
+
+// methodArgs are the positions of the method among the arguments of the
+// functions creating requests, by package and function.
+var methodArgs = map[[2]string]int{
+	{"http", "NewRequest"}:            0,
+	{"http", "NewRequestWithContext"}: 1,
+	{"httptest", "NewRequest"}:        0,
+}
+
+// isMethod reports whether the node under the cursor is where a method is
+// expected: the method argument of the functions creating requests, or
+// the value set to or compared with a Method field. Other strings may
+// merely look like methods.
+func isMethod(c *astutil.Cursor) bool {
+	if call, ok := c.Parent().(*ast.CallExpr); ok && c.Name() == "Args" {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		i, ok := methodArgs[[2]string{pkg.Name, sel.Sel.Name}]
+		return ok && c.Index() == i
+	}
+	return isFieldValue(c, "Method")
+}
+
+// isStatus reports whether the node under the cursor is where a status
+// code is expected: the argument of WriteHeader, the code of http.Error, or
+// the value set to or compared with a StatusCode field.
+func isStatus(c *astutil.Cursor) bool {
+	if call, ok := c.Parent().(*ast.CallExpr); ok && c.Name() == "Args" {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		if sel.Sel.Name == "WriteHeader" {
+			return c.Index() == 0
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		return ok && pkg.Name == "http" && sel.Sel.Name == "Error" && c.Index() == 2
+	}
+	return isFieldValue(c, "StatusCode")
+}
+
+// isFieldValue reports whether the node under the cursor is the value of
+// the field in a composite literal, or is assigned to or compared for
+// equality with a selector of the field.
+func isFieldValue(c *astutil.Cursor, field string) bool {
+	isField := func(expr ast.Expr) bool {
+		switch x := expr.(type) {
+		case *ast.Ident:
+			return x.Name == field
+		case *ast.SelectorExpr:
+			return x.Sel.Name == field
+		}
+		return false
+	}
+
+	switch p := c.Parent().(type) {
+	case *ast.KeyValueExpr:
+		return c.Name() == "Value" && isField(p.Key)
+	case *ast.BinaryExpr:
+		if p.Op != token.EQL && p.Op != token.NEQ {
+			return false
+		}
+		other := p.X
+		if c.Name() == "X" {
+			other = p.Y
+		}
+		_, ok := other.(*ast.SelectorExpr)
+		return ok && isField(other)
+	case *ast.AssignStmt:
+		if c.Name() != "Rhs" || len(p.Lhs) != len(p.Rhs) {
+			return false
+		}
+		_, ok := p.Lhs[c.Index()].(*ast.SelectorExpr)
+		return ok && isField(p.Lhs[c.Index()])
+	}
+	return false
+}
+
+func swapStatus(name string) string {
+	code, ok := statusCodes[name]
+	if !ok {
+		return ""
+	}
+
+	swap := statusSwaps[code/100]
+	if name == swap[0] {
+		return swap[1]
+	}
+	return swap[0]
+}
+
+func swapMethodConstant(name string) string {
+	for method, constant := range methodConstants {
+		if constant == name {
+			return methodConstants[methodSwaps[method]]
+		}
+	}
+	return ""
+}
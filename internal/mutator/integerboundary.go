@@ -0,0 +1,57 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "IntegerBoundary",
+		Version:     "1.0.0",
+		Types:       true,
+		Packs:       []string{"numeric"},
+		Description: "Adds and subtracts 1 to integer literals, one mutant each, exposing off-by-one errors the tests don't catch. Array lengths are left alone, as are negative values of unsigned types.",
+		Before:      "if len(items) > 10 {",
+		After:       "if len(items) > 11 {",
+		Mutations:   integerBoundary,
+	})
+}
+
+func integerBoundary(c *astutil.Cursor, info *types.Info) []Mutation {
+	lit, ok := c.Node().(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return nil
+	}
+
+	// changing the length changes the type of an array
+	if _, ok := c.Parent().(*ast.ArrayType); ok {
+		return nil
+	}
+
+	n, err := strconv.ParseInt(lit.Value, 0, 64)
+	if err != nil {
+		return nil
+	}
+
+	var apply []func()
+	for _, v := range []int64{n + 1, n - 1} {
+		if v < 0 {
+			tv, ok := info.Types[lit]
+			if !ok || isUnsigned(tv.Type) {
+				continue
+			}
+		}
+
+		value := strconv.FormatInt(v, 10)
+		apply = append(apply, func() {
+			lit.Value = value
+		})
+	}
+
+	return mutations(apply...)
+}
@@ -0,0 +1,77 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "Iterator",
+		Version:     "1.0.0",
+		Slow:        true,
+		Packs:       []string{"logic"},
+		Description: "Perturbs range-over-func iterators: stops after the first yield, ignores the result of yield or drops an unchecked yield. Iterators are recognized by their yield function, named as by convention.",
+		Before: `if !yield(v) {
+	return
+}`,
+		After: `{
+	yield(v)
+	return
+}`,
+		Mutations: iterator,
+	})
+}
+
+func iterator(c *astutil.Cursor, _ *types.Info) []Mutation {
+	switch stmt := c.Node().(type) {
+	case *ast.IfStmt:
+		// if !yield(v) { return }
+		if stmt.Init != nil || stmt.Else != nil {
+			return nil
+		}
+
+		not, ok := stmt.Cond.(*ast.UnaryExpr)
+		if !ok || not.Op != token.NOT || !isYield(not.X) {
+			return nil
+		}
+		call := &ast.ExprStmt{X: not.X}
+
+		return mutations(
+			// stop after the first element
+			func() {
+				c.Replace(&ast.BlockStmt{
+					List: append([]ast.Stmt{call}, stmt.Body.List...),
+				})
+			},
+			// skip the check, yielding after the loop body asked to stop
+			func() {
+				c.Replace(call)
+			},
+		)
+
+	case *ast.ExprStmt:
+		// statements can only be removed from a list
+		if !isYield(stmt.X) || c.Index() < 0 {
+			return nil
+		}
+
+		return mutations(c.Delete)
+	}
+
+	return nil
+}
+
+// isYield reports whether expr is a call to a function named yield.
+func isYield(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	id, ok := call.Fun.(*ast.Ident)
+	return ok && id.Name == "yield"
+}
@@ -0,0 +1,36 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "LoopCondition",
+		Version:     "1.0.0",
+		Packs:       []string{"logic"},
+		Description: "Replaces the condition of for loops with false, so their body never runs, exposing loops whose work no test verifies. Loops without a condition and range loops are left alone.",
+		Before:      "for i := 0; i < len(items); i++ {",
+		After:       "for i := 0; false; i++ {",
+		Mutations:   loopCondition,
+	})
+}
+
+func loopCondition(c *astutil.Cursor, _ *types.Info) []Mutation {
+	loop, ok := c.Node().(*ast.ForStmt)
+	if !ok || loop.Cond == nil {
+		return nil
+	}
+	if id, ok := loop.Cond.(*ast.Ident); ok && id.Name == "false" {
+		return nil
+	}
+
+	// loops with a condition never terminate a function, so the code
+	// after them still compiles
+	return []Mutation{{Pos: loop.Cond.Pos(), Apply: func() {
+		loop.Cond = ast.NewIdent("false")
+	}}}
+}
@@ -0,0 +1,233 @@
+// Package mutator contains the mutation operators applied by selene.
+package mutator
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Mutator is a mutation operator. Mutations is called for every node of
+// the code being mutated, in the order of the post function of
+// astutil.Apply, and returns each way the node under the cursor can be
+// mutated, none if the mutator isn't interested in it. Mutations must be
+// applied before the cursor moves on; selene applies at most one of them
+// per parse, so every mutant is applied on its own.
+//
+// Version must be bumped whenever the mutations produced change, as it
+// invalidates cached scans and tells results of the old behavior apart.
+// OptIn mutators only run when asked for by name or pack, as their mutants
+// are only killed by specific kinds of tests. Slow mutators make code block
+// or spin, so their mutants are often only killed by the go test timeout;
+// quick runs leave them out. Packs group related mutators, so they can be
+// enabled together.
+//
+// Settings, if set, returns the configuration the mutations depend on,
+// so cached scans made with another one aren't reused. Configure, if set,
+// returns the mutator making its mutations with the settings of a Config;
+// registered mutators use the defaults.
+//
+// The info passed to Mutations is nil unless Types is set, as type checking
+// the package is costly. It may be incomplete when the package or its
+// dependencies don't type check, so mutators must cope with missing types.
+type Mutator struct {
+	Name        string                                               `json:"name"`
+	Version     string                                               `json:"version"` // semantic version, as in 1.0.0
+	OptIn       bool                                                 `json:"optIn"`
+	Slow        bool                                                 `json:"slow"`
+	Packs       []string                                             `json:"packs"`
+	Types       bool                                                 `json:"types"`
+	Description string                                               `json:"description"`
+	Before      string                                               `json:"before"` // example code before the mutation
+	After       string                                               `json:"after"`  // the same example after the mutation
+	Mutations   func(c *astutil.Cursor, info *types.Info) []Mutation `json:"-"`
+	Settings    func() string                                        `json:"-"`
+	Configure   func(cfg Config) Mutator                             `json:"-"`
+}
+
+// Config holds the settings of the mutators that can be configured. The
+// zero value keeps the defaults.
+type Config struct {
+	RetryNames []*regexp.Regexp // names of retry counters and limits
+	GoRemove   bool             // remove the calls of go statements too
+}
+
+// WithConfig returns the mutators configured with cfg. The registered
+// mutators are left unchanged, so runs with different configs can share
+// them.
+func WithConfig(mutators []Mutator, cfg Config) []Mutator {
+	configured := make([]Mutator, len(mutators))
+	for i, m := range mutators {
+		configured[i] = m
+		if m.Configure != nil {
+			configured[i] = m.Configure(cfg)
+		}
+	}
+	return configured
+}
+
+// Mutation is one way of mutating a node. Pos is where the change is made,
+// when it is within the node rather than at its start, as for the keys of a
+// composite literal.
+//
+// Linked are changes to other files of the package made along with Apply,
+// as a single mutant, for mutations spanning files, such as changing a
+// constant and its uses. Their nodes come from the info, so mutators making
+// them need Types.
+type Mutation struct {
+	Pos    token.Pos
+	Apply  func()
+	Linked []Edit
+}
+
+// Edit is a change to a node of another file of the package. Pos is the
+// position of the node, telling which file the change is made in.
+type Edit struct {
+	Pos   token.Pos
+	Apply func()
+}
+
+// mutations wraps functions mutating the node under the cursor.
+func mutations(apply ...func()) []Mutation {
+	ms := make([]Mutation, len(apply))
+	for i, f := range apply {
+		ms[i] = Mutation{Apply: f}
+	}
+	return ms
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Mutator{} // by lower case name
+)
+
+// Register adds a mutator to the registry. It is meant to be called from
+// the init function of the file declaring the mutator, and panics if the
+// name is already taken, ignoring case, or if the version is missing.
+func Register(m Mutator) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if m.Version == "" {
+		panic(fmt.Sprintf("mutator: %s has no version", m.Name))
+	}
+
+	key := strings.ToLower(m.Name)
+	if _, ok := registry[key]; ok {
+		panic(fmt.Sprintf("mutator: Register called twice for %s", m.Name))
+	}
+	registry[key] = m
+}
+
+// Defaults returns the mutators that run unless asked otherwise, all but
+// the opt-in ones, sorted by name.
+func Defaults() []Mutator {
+	var defaults []Mutator
+	for _, m := range All() {
+		if !m.OptIn {
+			defaults = append(defaults, m)
+		}
+	}
+	return defaults
+}
+
+// All returns the registered mutators sorted by name.
+func All() []Mutator {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]Mutator, 0, len(registry))
+	for _, m := range registry {
+		all = append(all, m)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+// Get returns the mutator with the given name, ignoring case.
+func Get(name string) (Mutator, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	m, ok := registry[strings.ToLower(name)]
+	return m, ok
+}
+
+// Packs returns the names of the packs of the registered mutators, sorted.
+func Packs() []string {
+	seen := map[string]bool{}
+	var packs []string
+	for _, m := range All() {
+		for _, p := range m.Packs {
+			if !seen[p] {
+				seen[p] = true
+				packs = append(packs, p)
+			}
+		}
+	}
+
+	sort.Strings(packs)
+	return packs
+}
+
+// Pack returns the mutators of the pack with the given name, ignoring
+// case, sorted by name. Opt-in mutators are included.
+func Pack(name string) []Mutator {
+	var members []Mutator
+	for _, m := range All() {
+		for _, p := range m.Packs {
+			if strings.EqualFold(p, name) {
+				members = append(members, m)
+				break
+			}
+		}
+	}
+	return members
+}
+
+// Suggest returns the name of the registered mutator closest to name, or
+// an empty string if none is close enough to be a likely typo.
+func Suggest(name string) string {
+	var best string
+	bestDist := len(name)/3 + 1
+	for _, m := range All() {
+		d := distance(strings.ToLower(name), strings.ToLower(m.Name))
+		if d <= bestDist {
+			best, bestDist = m.Name, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
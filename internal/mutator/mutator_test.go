@@ -0,0 +1,71 @@
+package mutator
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"negation", "negation", 0},
+		{"negation", "negatoin", 2},
+		{"kitten", "sitting", 3},
+		{"errornil", "errornill", 1},
+	}
+
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := map[string]string{
+		"Negation":  "Negation",
+		"negaton":   "Negation",
+		"ErrorNill": "ErrorNil",
+		"nothing":   "",
+	}
+
+	for name, want := range tests {
+		if got := Suggest(name); got != want {
+			t.Errorf("Suggest(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWithConfig(t *testing.T) {
+	retry, ok := Get("Retry")
+	if !ok {
+		t.Fatal("Retry isn't registered")
+	}
+	goStatement, ok := Get("GoStatement")
+	if !ok {
+		t.Fatal("GoStatement isn't registered")
+	}
+
+	cfg := Config{RetryNames: []*regexp.Regexp{regexp.MustCompile(`backoff`)}, GoRemove: true}
+	configured := WithConfig([]Mutator{retry, goStatement}, cfg)
+
+	if got := configured[0].Settings(); got != "backoff" {
+		t.Errorf("configured Retry settings = %q, want %q", got, "backoff")
+	}
+	if got := configured[1].Settings(); got != "remove" {
+		t.Errorf("configured GoStatement settings = %q, want %q", got, "remove")
+	}
+
+	// runs with other configs share the registered mutators
+	for _, m := range []Mutator{retry, goStatement} {
+		registered, _ := Get(m.Name)
+		if registered.Settings() == "backoff" || registered.Settings() == "remove" {
+			t.Errorf("WithConfig changed the registered %s", m.Name)
+		}
+	}
+}
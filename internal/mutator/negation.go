@@ -0,0 +1,73 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "Negation",
+		Version:     "1.0.0",
+		Types:       true,
+		Packs:       []string{"logic"},
+		Description: "Removes ! from boolean expressions anywhere, and negates the boolean values assigned, declared and passed as arguments. Returned values are left to BooleanReturn and if conditions without ! to ReverseIfCond.",
+		Before:      "cache.Put(key, value, !expired)",
+		After:       "cache.Put(key, value, expired)",
+		Mutations:   negation,
+	})
+}
+
+func negation(c *astutil.Cursor, info *types.Info) []Mutation {
+	switch n := c.Node().(type) {
+	case *ast.UnaryExpr:
+		if n.Op != token.NOT {
+			return nil
+		}
+		if _, ok := c.Parent().(*ast.ReturnStmt); ok {
+			return nil
+		}
+		return []Mutation{{Pos: n.Pos(), Apply: func() {
+			c.Replace(n.X)
+		}}}
+	case *ast.AssignStmt:
+		if n.Tok != token.ASSIGN && n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+			return nil
+		}
+		return negations(n.Rhs, info)
+	case *ast.ValueSpec:
+		return negations(n.Values, info)
+	case *ast.CallExpr:
+		// conversions have a type instead of a function
+		if tv, ok := info.Types[n.Fun]; ok && tv.IsType() {
+			return nil
+		}
+		return negations(n.Args, info)
+	}
+	return nil
+}
+
+// negations negates each boolean expression of exprs in place, one
+// mutation each. Negated expressions are left to the removal of !.
+func negations(exprs []ast.Expr, info *types.Info) []Mutation {
+	var ms []Mutation
+	for i, expr := range exprs {
+		i := i
+		if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.NOT {
+			continue
+		}
+		tv, ok := info.Types[expr]
+		if !ok || !isBool(tv.Type) {
+			continue
+		}
+
+		negated := negate(expr)
+		ms = append(ms, Mutation{Pos: expr.Pos(), Apply: func() {
+			exprs[i] = negated
+		}})
+	}
+	return ms
+}
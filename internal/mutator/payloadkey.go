@@ -0,0 +1,124 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "PayloadKey",
+		Version:     "1.0.0",
+		Packs:       []string{"web", "data"},
+		Description: "Renames the keys of map[string]any and map[string]interface{} literals, and the names in json and yaml struct tags, so serialized payloads change shape.",
+		Before:      `payload := map[string]any{"id": id}`,
+		After:       `payload := map[string]any{"id_": id}`,
+		Mutations:   payloadKey,
+	})
+}
+
+// renameKey is how keys are renamed: visibly, and unlikely to clash with
+// another key.
+func renameKey(key string) string {
+	return key + "_"
+}
+
+func payloadKey(c *astutil.Cursor, _ *types.Info) []Mutation {
+	switch x := c.Node().(type) {
+	case *ast.CompositeLit:
+		if !isPayloadMap(x.Type) {
+			return nil
+		}
+
+		var ms []Mutation
+		for _, elt := range x.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+
+			lit, ok := kv.Key.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+
+			key, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				continue
+			}
+
+			ms = append(ms, Mutation{
+				Pos: lit.Pos(),
+				Apply: func() {
+					lit.Value = strconv.Quote(renameKey(key))
+				},
+			})
+		}
+		return ms
+
+	case *ast.Field:
+		if x.Tag == nil {
+			return nil
+		}
+
+		tag, err := strconv.Unquote(x.Tag.Value)
+		if err != nil {
+			return nil
+		}
+
+		var ms []Mutation
+		for _, format := range []string{"json", "yaml"} {
+			value, ok := reflect.StructTag(tag).Lookup(format)
+			name, opts, _ := strings.Cut(value, ",")
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+
+			mutated := strings.Replace(tag, format+`:"`+value+`"`, format+`:"`+renameKey(name)+optsSuffix(opts)+`"`, 1)
+			ms = append(ms, Mutation{
+				Pos: x.Tag.Pos(),
+				Apply: func() {
+					x.Tag.Value = quoteLike(x.Tag.Value, mutated)
+				},
+			})
+		}
+		return ms
+	}
+
+	return nil
+}
+
+func optsSuffix(opts string) string {
+	if opts == "" {
+		return ""
+	}
+	return "," + opts
+}
+
+// isPayloadMap reports whether expr is map[string]any or
+// map[string]interface{}.
+func isPayloadMap(expr ast.Expr) bool {
+	m, ok := expr.(*ast.MapType)
+	if !ok {
+		return false
+	}
+
+	key, ok := m.Key.(*ast.Ident)
+	if !ok || key.Name != "string" {
+		return false
+	}
+
+	switch v := m.Value.(type) {
+	case *ast.Ident:
+		return v.Name == "any"
+	case *ast.InterfaceType:
+		return len(v.Methods.List) == 0
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "ReceiverNilCheck",
+		Version:     "1.1.0",
+		Packs:       []string{"logic"},
+		Description: "Removes the nil receiver guard at the top of pointer receiver methods, unless it holds the only return the method ends with.",
+		Before: `func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return l.len
+}`,
+		After: `func (l *List) Len() int {
+	return l.len
+}`,
+		Mutations: receiverNilCheck,
+	})
+}
+
+func receiverNilCheck(c *astutil.Cursor, _ *types.Info) []Mutation {
+	fn, ok := c.Node().(*ast.FuncDecl)
+	if !ok || fn.Recv == nil || fn.Body == nil || len(fn.Body.List) == 0 {
+		return nil
+	}
+
+	recv := fn.Recv.List[0]
+	if _, ok := recv.Type.(*ast.StarExpr); !ok || len(recv.Names) == 0 {
+		return nil
+	}
+	name := recv.Names[0].Name
+
+	guard, ok := fn.Body.List[0].(*ast.IfStmt)
+	if !ok || guard.Init != nil || guard.Else != nil || !isNilCheck(guard.Cond, name) {
+		return nil
+	}
+
+	// the method must still end with a return, or the like
+	rest := fn.Body.List[1:]
+	if fn.Type.Results != nil && !endsTerminating(rest) {
+		return nil
+	}
+
+	return []Mutation{{
+		Pos: guard.Pos(),
+		Apply: func() {
+			fn.Body.List = fn.Body.List[1:]
+		},
+	}}
+}
+
+// isNilCheck reports whether cond is name == nil or nil == name.
+func isNilCheck(cond ast.Expr, name string) bool {
+	bin, ok := cond.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.EQL {
+		return false
+	}
+
+	isIdent := func(expr ast.Expr, ident string) bool {
+		id, ok := expr.(*ast.Ident)
+		return ok && id.Name == ident
+	}
+
+	return isIdent(bin.X, name) && isIdent(bin.Y, "nil") ||
+		isIdent(bin.X, "nil") && isIdent(bin.Y, name)
+}
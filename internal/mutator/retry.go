@@ -0,0 +1,172 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(retryMutator(defaultRetryNames))
+}
+
+// defaultRetryNames match the names of retry counters and limits.
+var defaultRetryNames = []*regexp.Regexp{regexp.MustCompile(`(?i)retr(y|ies)|attempt|tries`)}
+
+// retryMutator returns the Retry mutator for counters and limits whose
+// names match retryNames.
+func retryMutator(retryNames []*regexp.Regexp) Mutator {
+	return Mutator{
+		Name:        "Retry",
+		Version:     "1.0.0",
+		Packs:       []string{"concurrency", "resilience"},
+		Description: "Sets retry counts to 0 and 1, and zeroes the time.Sleep and time.After backoffs of retry loops. Retry counts are the integer literals bounding loops over, or assigned to, names matching the retry patterns, which can be set in the config.",
+		Before:      "for attempt := 0; attempt < 3; attempt++ {",
+		After:       "for attempt := 0; attempt < 1; attempt++ {",
+		Mutations: func(c *astutil.Cursor, _ *types.Info) []Mutation {
+			return retry(c, retryNames)
+		},
+		Settings: func() string {
+			var patterns []string
+			for _, re := range retryNames {
+				patterns = append(patterns, re.String())
+			}
+			return strings.Join(patterns, "\x00")
+		},
+		Configure: func(cfg Config) Mutator {
+			if len(cfg.RetryNames) == 0 {
+				return retryMutator(defaultRetryNames)
+			}
+			return retryMutator(cfg.RetryNames)
+		},
+	}
+}
+
+func retry(c *astutil.Cursor, retryNames []*regexp.Regexp) []Mutation {
+	switch x := c.Node().(type) {
+	case *ast.ForStmt:
+		bin, ok := x.Cond.(*ast.BinaryExpr)
+		if !ok || !isRetryName(bin.X, retryNames) && !isRetryName(bin.Y, retryNames) {
+			return nil
+		}
+
+		var ms []Mutation
+		for _, operand := range []ast.Expr{bin.X, bin.Y} {
+			ms = append(ms, retryCounts(operand)...)
+		}
+		return append(ms, backoffs(x.Body)...)
+
+	case *ast.ValueSpec:
+		var ms []Mutation
+		for i, name := range x.Names {
+			if i < len(x.Values) && isRetryName(name, retryNames) {
+				ms = append(ms, retryCounts(x.Values[i])...)
+			}
+		}
+		return ms
+
+	case *ast.AssignStmt:
+		// the counter of a retry loop starts where it has to
+		if _, ok := c.Parent().(*ast.ForStmt); ok {
+			return nil
+		}
+
+		var ms []Mutation
+		for i, lhs := range x.Lhs {
+			if len(x.Lhs) == len(x.Rhs) && isRetryName(lhs, retryNames) {
+				ms = append(ms, retryCounts(x.Rhs[i])...)
+			}
+		}
+		return ms
+
+	case *ast.KeyValueExpr:
+		if isRetryName(x.Key, retryNames) {
+			return retryCounts(x.Value)
+		}
+	}
+
+	return nil
+}
+
+// isRetryName reports whether expr is an identifier or a selector whose
+// name matches one of retryNames.
+func isRetryName(expr ast.Expr, retryNames []*regexp.Regexp) bool {
+	var name string
+	switch x := expr.(type) {
+	case *ast.Ident:
+		name = x.Name
+	case *ast.SelectorExpr:
+		name = x.Sel.Name
+	default:
+		return false
+	}
+
+	for _, re := range retryNames {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryCounts returns the mutations setting expr to 0 and 1, if it is an
+// integer literal.
+func retryCounts(expr ast.Expr) []Mutation {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return nil
+	}
+
+	var ms []Mutation
+	for _, count := range []string{"0", "1"} {
+		count := count
+		if lit.Value == count {
+			continue
+		}
+		ms = append(ms, Mutation{
+			Pos: lit.Pos(),
+			Apply: func() {
+				lit.Value = count
+			},
+		})
+	}
+	return ms
+}
+
+// backoffs returns the mutations zeroing the duration of the time.Sleep and
+// time.After calls of a retry loop body. Function literals are left out, as
+// they may not run within the loop.
+func backoffs(body *ast.BlockStmt) []Mutation {
+	var ms []Mutation
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			sel, ok := x.Fun.(*ast.SelectorExpr)
+			if !ok || len(x.Args) != 1 {
+				return true
+			}
+			pkg, ok := sel.X.(*ast.Ident)
+			if !ok || pkg.Name != "time" || sel.Sel.Name != "Sleep" && sel.Sel.Name != "After" {
+				return true
+			}
+
+			if lit, ok := x.Args[0].(*ast.BasicLit); ok && lit.Value == "0" {
+				return true
+			}
+			ms = append(ms, Mutation{
+				Pos: x.Pos(),
+				Apply: func() {
+					x.Args[0] = &ast.BasicLit{Kind: token.INT, Value: "0"}
+				},
+			})
+		}
+		return true
+	})
+	return ms
+}
@@ -0,0 +1,75 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "ReturnValue",
+		Version:     "1.0.0",
+		Types:       true,
+		Packs:       []string{"numeric"},
+		Description: "Replaces numeric return values with 0, 1 and -1, one mutant each, skipping the value already returned and -1 for unsigned types.",
+		Before:      "return len(items) * price",
+		After:       "return 0",
+		Mutations:   returnValue,
+	})
+}
+
+// returnValues are the values replacing numeric results, in order.
+var returnValues = []string{"0", "1", "-1"}
+
+func returnValue(c *astutil.Cursor, info *types.Info) []Mutation {
+	ret, ok := c.Node().(*ast.ReturnStmt)
+	if !ok {
+		return nil
+	}
+
+	var ms []Mutation
+	for i, result := range ret.Results {
+		i := i
+		tv, ok := info.Types[result]
+		if !ok || !isNumeric(tv.Type) {
+			continue
+		}
+
+		for _, v := range returnValues {
+			if tv.Value != nil && tv.Value.ExactString() == v {
+				continue
+			}
+			if v == "-1" && isUnsigned(tv.Type) {
+				continue
+			}
+
+			lit := literal(v)
+			ms = append(ms, Mutation{Pos: result.Pos(), Apply: func() {
+				ret.Results[i] = lit
+			}})
+		}
+	}
+
+	return ms
+}
+
+// literal returns the expression of an integer constant.
+func literal(v string) ast.Expr {
+	if v[0] == '-' {
+		return &ast.UnaryExpr{Op: token.SUB, X: &ast.BasicLit{Kind: token.INT, Value: v[1:]}}
+	}
+	return &ast.BasicLit{Kind: token.INT, Value: v}
+}
+
+func isNumeric(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsNumeric != 0
+}
+
+func isUnsigned(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsUnsigned != 0
+}
@@ -0,0 +1,40 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "ReverseIfCond",
+		Version:     "1.0.0",
+		Packs:       []string{"logic"},
+		Description: "Negates binary expressions used as if conditions.",
+		Before:      "if x > 0 {",
+		After:       "if !(x > 0) {",
+		Mutations:   reverseIfCond,
+	})
+}
+
+func reverseIfCond(c *astutil.Cursor, _ *types.Info) []Mutation {
+	stmt, ok := c.Node().(*ast.IfStmt)
+	if !ok {
+		return nil
+	}
+
+	bin, ok := stmt.Cond.(*ast.BinaryExpr)
+	if !ok {
+		return nil
+	}
+
+	return mutations(func() {
+		stmt.Cond = &ast.UnaryExpr{
+			Op: token.NOT,
+			X:  bin,
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "SelectDefault",
+		Version:     "1.1.0",
+		Slow:        true,
+		Packs:       []string{"concurrency"},
+		Description: "Adds an empty default clause to blocking select statements, making them non-blocking. Selects a function may end with, every clause returning, are left alone, as the function wouldn't compile with the default clause; those ending loop bodies are mutated.",
+		Before: `select {
+case jobs <- job:
+}`,
+		After: `select {
+case jobs <- job:
+default:
+}`,
+		Mutations: selectDefault,
+	})
+}
+
+func selectDefault(c *astutil.Cursor, _ *types.Info) []Mutation {
+	switch x := c.Node().(type) {
+	case *ast.SelectStmt:
+		// a default clause makes a terminating select fall through
+		if isTerminating(x, "") && mayEndFunc(c) {
+			return nil
+		}
+		return addDefault(x)
+
+	case *ast.BlockStmt:
+		// but loop bodies end no function, so the last select of one
+		// is mutated along with its loop
+		switch c.Parent().(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+		default:
+			return nil
+		}
+		if len(x.List) == 0 {
+			return nil
+		}
+		stmt, ok := x.List[len(x.List)-1].(*ast.SelectStmt)
+		if !ok || !isTerminating(stmt, "") {
+			return nil
+		}
+		return addDefault(stmt)
+	}
+
+	return nil
+}
+
+// addDefault returns the mutation adding an empty default clause to a
+// blocking select.
+func addDefault(stmt *ast.SelectStmt) []Mutation {
+	if len(stmt.Body.List) == 0 {
+		return nil
+	}
+
+	for _, clause := range stmt.Body.List {
+		if clause.(*ast.CommClause).Comm == nil {
+			return nil // already non-blocking
+		}
+	}
+
+	return []Mutation{{
+		Pos: stmt.Pos(),
+		Apply: func() {
+			stmt.Body.List = append(stmt.Body.List, &ast.CommClause{})
+		},
+	}}
+}
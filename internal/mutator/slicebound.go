@@ -0,0 +1,54 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "SliceBound",
+		Version:     "1.0.0",
+		Packs:       []string{"numeric", "data"},
+		Description: "Moves the low bound of slice expressions up by 1 and the high bound down by 1, one mutant each, exposing parsing and windowing code whose tests never check the first or last element. Missing high bounds are left alone.",
+		Before:      "header := line[start:end]",
+		After:       "header := line[start+1 : end]",
+		Mutations:   sliceBound,
+	})
+}
+
+func sliceBound(c *astutil.Cursor, _ *types.Info) []Mutation {
+	x, ok := c.Node().(*ast.SliceExpr)
+	if !ok {
+		return nil
+	}
+
+	apply := []func(){
+		func() {
+			if x.Low == nil {
+				x.Low = &ast.BasicLit{Kind: token.INT, Value: "1"}
+				return
+			}
+			x.Low = offset(x.Low, token.ADD)
+		},
+	}
+	if x.High != nil {
+		apply = append(apply, func() {
+			x.High = offset(x.High, token.SUB)
+		})
+	}
+
+	return mutations(apply...)
+}
+
+// offset returns expr plus or minus 1, in parentheses if it is a binary
+// expression itself.
+func offset(expr ast.Expr, op token.Token) ast.Expr {
+	if _, ok := expr.(*ast.BinaryExpr); ok {
+		expr = &ast.ParenExpr{X: expr}
+	}
+	return &ast.BinaryExpr{X: expr, Op: op, Y: &ast.BasicLit{Kind: token.INT, Value: "1"}}
+}
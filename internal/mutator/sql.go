@@ -0,0 +1,88 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "SQL",
+		Version:     "1.0.0",
+		OptIn:       true,
+		Packs:       []string{"data"},
+		Description: "Perturbs string literals that look like SQL queries: drops the WHERE clause, swaps ASC and DESC, and turns the first = of the WHERE clause into <>. Only integration tests checking query results can kill these mutants.",
+		Before:      `"SELECT name FROM users WHERE id = $1 ORDER BY name ASC"`,
+		After:       `"SELECT name FROM users ORDER BY name ASC"`,
+		Mutations:   sqlQuery,
+	})
+}
+
+var (
+	sqlStatement = regexp.MustCompile(`(?is)^\s*(select|insert|update|delete|with)\b.*\b(from|into|set)\b`)
+	sqlWhere     = regexp.MustCompile(`(?is)\s+where\s+.*?(\s+(group\s+by|order\s+by|limit|having|returning)\b|;|$)`)
+	sqlOrder     = regexp.MustCompile(`(?i)\b(asc|desc)\b`)
+	sqlEquals    = regexp.MustCompile(`([^<>!=])=([^=])`)
+)
+
+func sqlQuery(c *astutil.Cursor, _ *types.Info) []Mutation {
+	lit, ok := c.Node().(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil
+	}
+
+	// imports and struct tags are strings too, but never SQL
+	switch c.Parent().(type) {
+	case *ast.ImportSpec, *ast.Field:
+		return nil
+	}
+
+	query, err := strconv.Unquote(lit.Value)
+	if err != nil || !sqlStatement.MatchString(query) {
+		return nil
+	}
+
+	var queries []string
+
+	if loc := sqlWhere.FindStringSubmatchIndex(query); loc != nil {
+		// keep whatever ends the clause
+		queries = append(queries, query[:loc[0]]+query[loc[2]:loc[3]]+query[loc[1]:])
+
+		where := query[loc[0]:loc[1]]
+		if eq := sqlEquals.FindStringSubmatchIndex(where); eq != nil {
+			mutated := where[:eq[3]] + "<>" + where[eq[4]:]
+			queries = append(queries, query[:loc[0]]+mutated+query[loc[1]:])
+		}
+	}
+
+	if loc := sqlOrder.FindStringIndex(query); loc != nil {
+		swap := "DESC"
+		if strings.EqualFold(query[loc[0]:loc[1]], "desc") {
+			swap = "ASC"
+		}
+		queries = append(queries, query[:loc[0]]+swap+query[loc[1]:])
+	}
+
+	var apply []func()
+	for _, q := range queries {
+		q := q
+		apply = append(apply, func() {
+			lit.Value = quoteLike(lit.Value, q)
+		})
+	}
+	return mutations(apply...)
+}
+
+// quoteLike quotes s as a raw string if original was one and s allows it.
+func quoteLike(original, s string) string {
+	if strings.HasPrefix(original, "`") && !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
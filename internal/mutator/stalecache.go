@@ -0,0 +1,87 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "StaleCache",
+		Version:     "1.0.0",
+		Packs:       []string{"data"},
+		Description: "Removes statements that invalidate cached values: map deletes, clear, Delete method calls such as sync.Map.Delete, and fields reset to their zero value.",
+		Before:      "delete(c.entries, key)",
+		After:       "// removed",
+		Mutations:   staleCache,
+	})
+}
+
+func staleCache(c *astutil.Cursor, _ *types.Info) []Mutation {
+	// statements can only be removed from a list
+	if c.Index() < 0 {
+		return nil
+	}
+
+	switch stmt := c.Node().(type) {
+	case *ast.ExprStmt:
+		if !invalidates(stmt.X) {
+			return nil
+		}
+	case *ast.AssignStmt:
+		if !resetsField(stmt) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return mutations(c.Delete)
+}
+
+// invalidates reports whether expr is a call to delete, clear or a Delete
+// method.
+func invalidates(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name == "delete" || fun.Name == "clear"
+	case *ast.SelectorExpr:
+		return fun.Sel.Name == "Delete"
+	}
+	return false
+}
+
+// resetsField reports whether stmt assigns a zero value to a field, as in
+// c.value = nil.
+func resetsField(stmt *ast.AssignStmt) bool {
+	if stmt.Tok != token.ASSIGN || len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+		return false
+	}
+
+	if _, ok := stmt.Lhs[0].(*ast.SelectorExpr); !ok {
+		return false
+	}
+
+	return isZero(stmt.Rhs[0])
+}
+
+// isZero reports whether expr is a literal zero value.
+func isZero(expr ast.Expr) bool {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return x.Name == "nil" || x.Name == "false"
+	case *ast.BasicLit:
+		return x.Value == "0" || x.Value == `""` || x.Value == "``"
+	case *ast.CompositeLit:
+		return len(x.Elts) == 0
+	}
+	return false
+}
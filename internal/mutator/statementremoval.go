@@ -0,0 +1,198 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "StatementRemoval",
+		Version:     "1.1.0",
+		Types:       true,
+		Packs:       []string{"logic"},
+		Description: "Removes calls made only for their side effects, whose results are unused, exposing tests that never verify those effects. Logging, calls that never return, such as panic and os.Exit, the invalidations removed by StaleCache and the calls holding the only use of a local variable are left alone.",
+		Before:      "cache.Invalidate(key)",
+		After:       "// removed",
+		Mutations:   statementRemoval,
+	})
+}
+
+func statementRemoval(c *astutil.Cursor, info *types.Info) []Mutation {
+	// statements can only be removed from a list
+	if c.Index() < 0 {
+		return nil
+	}
+
+	stmt, ok := c.Node().(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+	call, ok := stmt.X.(*ast.CallExpr)
+	if !ok || invalidates(call) || !removable(call.Fun) || usesOnly(info, stmt) {
+		return nil
+	}
+
+	return mutations(c.Delete)
+}
+
+// removable reports whether a call to fun can be removed: it isn't
+// logging, and it returns, so the code still compiles without it.
+func removable(fun ast.Expr) bool {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun.Name != "panic"
+	case *ast.SelectorExpr:
+		// loggers are often fields, as in s.logger.Info
+		for x := fun.X; x != nil; {
+			switch e := x.(type) {
+			case *ast.Ident:
+				if isLogger(e.Name) {
+					return false
+				}
+				x = nil
+			case *ast.SelectorExpr:
+				if isLogger(e.Sel.Name) {
+					return false
+				}
+				x = e.X
+			case *ast.CallExpr:
+				x = e.Fun // as in log.With(...).Info
+			default:
+				x = nil
+			}
+		}
+
+		x, ok := fun.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		switch x.Name {
+		case "os":
+			return fun.Sel.Name != "Exit"
+		case "runtime":
+			return fun.Sel.Name != "Goexit"
+		}
+	}
+	return true
+}
+
+// isLogger reports whether name is that of a logging package or logger.
+func isLogger(name string) bool {
+	name = strings.ToLower(name)
+	return name == "log" || name == "slog" || strings.HasSuffix(name, "logger")
+}
+
+// usesOnly reports whether stmt holds the only use of a local variable,
+// which wouldn't compile without it. Parameters may go unused.
+func usesOnly(info *types.Info, stmt ast.Stmt) bool {
+	if info == nil {
+		return false
+	}
+
+	locals := map[*types.Var]int{} // uses within stmt
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := info.Uses[id].(*types.Var)
+		if ok && !v.IsField() && v.Pkg() != nil && v.Parent() != v.Pkg().Scope() {
+			locals[v]++
+		}
+		return true
+	})
+	if len(locals) == 0 {
+		return false
+	}
+
+	uses := usesOf(info)
+	for v, n := range locals {
+		if uses.counts[v] == n && !uses.params[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// packageUses are the use counts of the variables of a type checked
+// package, and its parameters, found once per check rather than for
+// every statement.
+type packageUses struct {
+	info   *types.Info
+	once   sync.Once
+	counts map[*types.Var]int
+	params map[*types.Var]bool
+}
+
+// maxPackageUses is how many type checks usesOf keeps the uses of, as
+// several packages are scanned at once.
+const maxPackageUses = 8
+
+var packagesUses struct {
+	sync.Mutex
+	recent []*packageUses
+}
+
+// usesOf returns the uses of the variables of info.
+func usesOf(info *types.Info) *packageUses {
+	packagesUses.Lock()
+	i := slices.IndexFunc(packagesUses.recent, func(u *packageUses) bool { return u.info == info })
+	var u *packageUses
+	if i >= 0 {
+		u = packagesUses.recent[i]
+	} else {
+		u = &packageUses{info: info}
+		packagesUses.recent = append(packagesUses.recent, u)
+		if len(packagesUses.recent) > maxPackageUses {
+			packagesUses.recent = packagesUses.recent[1:]
+		}
+	}
+	packagesUses.Unlock()
+
+	u.once.Do(func() {
+		u.counts = map[*types.Var]int{}
+		for _, obj := range info.Uses {
+			if v, ok := obj.(*types.Var); ok {
+				u.counts[v]++
+			}
+		}
+		u.params = parameters(info)
+	})
+	return u
+}
+
+// parameters returns the parameters, results and receivers of the
+// functions of info.
+func parameters(info *types.Info) map[*types.Var]bool {
+	params := map[*types.Var]bool{}
+	add := func(sig *types.Signature) {
+		if sig.Recv() != nil {
+			params[sig.Recv()] = true
+		}
+		for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				params[tuple.At(i)] = true
+			}
+		}
+	}
+
+	for _, obj := range info.Defs {
+		if fn, ok := obj.(*types.Func); ok {
+			add(fn.Type().(*types.Signature))
+		}
+	}
+	for expr, tv := range info.Types {
+		if _, ok := expr.(*ast.FuncLit); ok {
+			if sig, ok := tv.Type.(*types.Signature); ok {
+				add(sig)
+			}
+		}
+	}
+	return params
+}
@@ -0,0 +1,128 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "StructCompare",
+		Version:     "1.1.0",
+		Types:       true,
+		Packs:       []string{"logic"},
+		Description: "Replaces the comparison of two structs with one ignoring a field, for each field in turn, revealing which fields tests actually verify.",
+		Before:      "if got == want {",
+		After: `if func() bool {
+	x, y := got, want
+	return x.ID == y.ID
+}() {`,
+		Mutations: structCompare,
+	})
+}
+
+func structCompare(c *astutil.Cursor, info *types.Info) []Mutation {
+	bin, ok := c.Node().(*ast.BinaryExpr)
+	if !ok || bin.Op != token.EQL && bin.Op != token.NEQ {
+		return nil
+	}
+
+	tv, ok := info.Types[bin.X]
+	if !ok || tv.Type == nil {
+		return nil
+	}
+	st, ok := tv.Type.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	// blank fields are ignored by == already, and fields of other
+	// packages may not be accessible
+	pkg := checkedPackage(info)
+	var fields []string
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Name() == "_" {
+			continue
+		}
+		if !f.Exported() && f.Pkg() != pkg {
+			return nil
+		}
+		fields = append(fields, f.Name())
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	apply := make([]func(), len(fields))
+	for i := range fields {
+		i := i
+		apply[i] = func() {
+			c.Replace(compareExcept(bin, fields, i))
+		}
+	}
+	return mutations(apply...)
+}
+
+// compareExcept returns an expression comparing the operands of bin field
+// by field, except for fields[skip]. The operands are evaluated once, by
+// a function literal called in place, which needs neither a declaration
+// in the file nor a name for the type of the operands. Without any field
+// left to compare, they are only evaluated.
+func compareExcept(bin *ast.BinaryExpr, fields []string, skip int) ast.Expr {
+	x, y := ast.NewIdent("x"), ast.NewIdent("y")
+
+	var cond ast.Expr
+	for i, name := range fields {
+		if i == skip {
+			continue
+		}
+
+		eq := &ast.BinaryExpr{
+			X:  &ast.SelectorExpr{X: x, Sel: ast.NewIdent(name)},
+			Op: token.EQL,
+			Y:  &ast.SelectorExpr{X: y, Sel: ast.NewIdent(name)},
+		}
+		if cond == nil {
+			cond = eq
+		} else {
+			cond = &ast.BinaryExpr{X: cond, Op: token.LAND, Y: eq}
+		}
+	}
+	bind := &ast.AssignStmt{Lhs: []ast.Expr{x, y}, Tok: token.DEFINE, Rhs: []ast.Expr{bin.X, bin.Y}}
+	if cond == nil {
+		bind.Lhs, bind.Tok = []ast.Expr{ast.NewIdent("_"), ast.NewIdent("_")}, token.ASSIGN
+		cond = ast.NewIdent("true")
+	}
+
+	var expr ast.Expr = &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("bool")}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				bind,
+				&ast.ReturnStmt{Results: []ast.Expr{cond}},
+			}},
+		},
+	}
+
+	if bin.Op == token.NEQ {
+		expr = &ast.UnaryExpr{Op: token.NOT, X: expr}
+	}
+	return expr
+}
+
+// checkedPackage returns the package info was checked for.
+func checkedPackage(info *types.Info) *types.Package {
+	for _, obj := range info.Defs {
+		if obj != nil && obj.Pkg() != nil {
+			return obj.Pkg()
+		}
+	}
+	return nil
+}
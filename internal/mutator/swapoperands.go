@@ -0,0 +1,43 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "SwapOperands",
+		Version:     "1.0.0",
+		Packs:       []string{"logic", "numeric"},
+		Description: "Swaps the operands of relational expressions, keeping the operator.",
+		Before:      "if lo < hi {",
+		After:       "if hi < lo {",
+		Mutations:   swapOperands,
+	})
+}
+
+func swapOperands(c *astutil.Cursor, _ *types.Info) []Mutation {
+	bin, ok := c.Node().(*ast.BinaryExpr)
+	if !ok {
+		return nil
+	}
+
+	switch bin.Op {
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+	default:
+		return nil
+	}
+
+	// swapping identical operands changes nothing
+	if types.ExprString(bin.X) == types.ExprString(bin.Y) {
+		return nil
+	}
+
+	return mutations(func() {
+		bin.X, bin.Y = bin.Y, bin.X
+	})
+}
@@ -0,0 +1,124 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "SwitchCase",
+		Version:     "1.1.0",
+		Packs:       []string{"logic"},
+		Description: "Empties the body of switch cases, one mutant per case, exposing branches of state machines and dispatchers no test verifies. A final terminating statement, such as a return or panic, a goto or labeled break or continue, or a call exiting, such as os.Exit, is kept so the function still compiles and leaves the case the same way. Default clauses are left to SwitchDefault.",
+		Before: `case StateOpen:
+	conn.Close()
+	return StateClosed`,
+		After: `case StateOpen:
+	return StateClosed`,
+		Mutations: switchCase,
+	})
+}
+
+func switchCase(c *astutil.Cursor, _ *types.Info) []Mutation {
+	clause, ok := c.Node().(*ast.CaseClause)
+	if !ok || clause.List == nil {
+		return nil
+	}
+
+	body := clause.Body
+	var kept ast.Stmt
+	if len(body) > 0 && terminates(body[len(body)-1]) {
+		kept = body[len(body)-1]
+		body = body[:len(body)-1]
+	}
+	if len(body) == 0 || kept != nil && usesDeclared(kept, body) {
+		return nil
+	}
+
+	return mutations(func() {
+		clause.Body = clause.Body[len(body):]
+	})
+}
+
+// terminates reports whether stmt leaves a case for good: a terminating
+// statement, which a function may need to end with, a goto or a labeled
+// break or continue, whose label may have no other use, or a call exiting
+// the goroutine or program.
+func terminates(stmt ast.Stmt) bool {
+	if isTerminating(stmt, "") {
+		return true
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.BranchStmt:
+		return stmt.Label != nil
+	case *ast.ExprStmt:
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		switch pkg.Name {
+		case "os":
+			return sel.Sel.Name == "Exit"
+		case "runtime":
+			return sel.Sel.Name == "Goexit"
+		case "log":
+			return strings.HasPrefix(sel.Sel.Name, "Fatal") || strings.HasPrefix(sel.Sel.Name, "Panic")
+		}
+	}
+	return false
+}
+
+// usesDeclared reports whether stmt refers to a name declared by one of
+// the statements of list, which would be undefined without them.
+func usesDeclared(stmt ast.Stmt, list []ast.Stmt) bool {
+	declared := map[string]bool{}
+	for _, s := range list {
+		switch s := s.(type) {
+		case *ast.AssignStmt:
+			if s.Tok == token.DEFINE {
+				for _, lhs := range s.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok {
+						declared[id.Name] = true
+					}
+				}
+			}
+		case *ast.DeclStmt:
+			ast.Inspect(s, func(n ast.Node) bool {
+				if spec, ok := n.(*ast.ValueSpec); ok {
+					for _, id := range spec.Names {
+						declared[id.Name] = true
+					}
+				}
+				if spec, ok := n.(*ast.TypeSpec); ok {
+					declared[spec.Name.Name] = true
+				}
+				return true
+			})
+		case *ast.LabeledStmt:
+			declared[s.Label.Name] = true
+		}
+	}
+
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && declared[id.Name] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,69 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+func init() {
+	Register(Mutator{
+		Name:        "SwitchDefault",
+		Version:     "1.1.0",
+		Packs:       []string{"logic"},
+		Description: "Deletes the default clause of switch statements, exposing suites that never exercise the fallback path. Terminating switches a function may end with, every clause returning, are left alone, as the function wouldn't compile without their default clause.",
+		Before: `default:
+	return fmt.Errorf("unknown kind %q", kind)
+}`,
+		After:     `}`,
+		Mutations: switchDefault,
+	})
+}
+
+func switchDefault(c *astutil.Cursor, _ *types.Info) []Mutation {
+	var body *ast.BlockStmt
+	switch stmt := c.Node().(type) {
+	case *ast.SwitchStmt:
+		body = stmt.Body
+	case *ast.TypeSwitchStmt:
+		body = stmt.Body
+	default:
+		return nil
+	}
+
+	i := -1
+	for j, stmt := range body.List {
+		if stmt.(*ast.CaseClause).List == nil {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil
+	}
+
+	// cases falling through to the default one would fall elsewhere
+	if i > 0 {
+		prev := body.List[i-1].(*ast.CaseClause).Body
+		if len(prev) > 0 {
+			if branch, ok := prev[len(prev)-1].(*ast.BranchStmt); ok && branch.Tok == token.FALLTHROUGH {
+				return nil
+			}
+		}
+	}
+
+	// a switch is only terminating with a default clause, and a
+	// terminating one may be what ends its function
+	var label string
+	if labeled, ok := c.Parent().(*ast.LabeledStmt); ok {
+		label = labeled.Label.Name
+	}
+	if isTerminating(c.Node().(ast.Stmt), label) && mayEndFunc(c) {
+		return nil
+	}
+
+	return []Mutation{{Pos: body.List[i].Pos(), Apply: func() {
+		body.List = append(body.List[:i:i], body.List[i+1:]...)
+	}}}
+}
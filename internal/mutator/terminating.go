@@ -0,0 +1,163 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// isTerminating reports whether stmt is a terminating statement, as the
+// Go spec defines it: one a function with results may end with. label is
+// the label of stmt, if any, which its breaks may refer to.
+func isTerminating(stmt ast.Stmt, label string) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+
+	case *ast.BranchStmt:
+		return s.Tok == token.GOTO
+
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		id, ok := call.Fun.(*ast.Ident)
+		return ok && id.Name == "panic"
+
+	case *ast.BlockStmt:
+		return endsTerminating(s.List)
+
+	case *ast.IfStmt:
+		return s.Else != nil && isTerminating(s.Body, "") && isTerminating(s.Else, "")
+
+	case *ast.ForStmt:
+		return s.Cond == nil && !hasBreak(s.Body, label, true)
+
+	case *ast.LabeledStmt:
+		return isTerminating(s.Stmt, s.Label.Name)
+
+	case *ast.SwitchStmt:
+		return terminatingClauses(s.Body, label)
+
+	case *ast.TypeSwitchStmt:
+		return terminatingClauses(s.Body, label)
+
+	case *ast.SelectStmt:
+		for _, clause := range s.Body.List {
+			if !endsTerminating(clause.(*ast.CommClause).Body) || hasBreak(clause, label, true) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// endsTerminating reports whether the last statement of list, empty ones
+// left out, is a terminating statement.
+func endsTerminating(list []ast.Stmt) bool {
+	for i := len(list) - 1; i >= 0; i-- {
+		if _, ok := list[i].(*ast.EmptyStmt); !ok {
+			return isTerminating(list[i], "")
+		}
+	}
+	return false
+}
+
+// endsFallthrough reports whether list ends with a fallthrough, possibly
+// labeled.
+func endsFallthrough(list []ast.Stmt) bool {
+	if len(list) == 0 {
+		return false
+	}
+	stmt := list[len(list)-1]
+	if labeled, ok := stmt.(*ast.LabeledStmt); ok {
+		stmt = labeled.Stmt
+	}
+	branch, ok := stmt.(*ast.BranchStmt)
+	return ok && branch.Tok == token.FALLTHROUGH
+}
+
+// terminatingClauses reports whether the clauses of a switch make it a
+// terminating statement: one of them is default, each ends with a
+// terminating statement or a fallthrough, and none breaks out of it.
+func terminatingClauses(body *ast.BlockStmt, label string) bool {
+	hasDefault := false
+	for _, stmt := range body.List {
+		clause := stmt.(*ast.CaseClause)
+		if clause.List == nil {
+			hasDefault = true
+		}
+		if !endsTerminating(clause.Body) && !endsFallthrough(clause.Body) || hasBreak(clause, label, true) {
+			return false
+		}
+	}
+	return hasDefault
+}
+
+// hasBreak reports whether node has a break referring to the statement
+// labeled label, or, if implicit, to the statement node is the body of:
+// a break without label outside the nested for, switch and select
+// statements, whose own breaks refer to them.
+func hasBreak(node ast.Node, label string, implicit bool) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+
+		switch s := n.(type) {
+		case *ast.BranchStmt:
+			if s.Tok == token.BREAK && (s.Label == nil && implicit || s.Label != nil && s.Label.Name == label) {
+				found = true
+			}
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			if n != node {
+				found = hasBreak(nestedBody(s), label, false)
+				return false
+			}
+		case *ast.FuncLit:
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// nestedBody returns the body of a for, switch or select statement.
+func nestedBody(stmt ast.Node) ast.Node {
+	switch s := stmt.(type) {
+	case *ast.ForStmt:
+		return s.Body
+	case *ast.RangeStmt:
+		return s.Body
+	case *ast.SwitchStmt:
+		return s.Body
+	case *ast.TypeSwitchStmt:
+		return s.Body
+	case *ast.SelectStmt:
+		return s.Body
+	}
+	return nil
+}
+
+// mayEndFunc reports whether the statement under the cursor may be the
+// terminating statement its function ends with: it is the last one of
+// its block or clause, or labeled, as the place of the labeled statement
+// isn't known from the cursor.
+func mayEndFunc(c *astutil.Cursor) bool {
+	switch parent := c.Parent().(type) {
+	case *ast.LabeledStmt:
+		return true
+	case *ast.BlockStmt:
+		return c.Index() == len(parent.List)-1
+	case *ast.CaseClause:
+		return c.Index() == len(parent.Body)-1
+	case *ast.CommClause:
+		return c.Index() == len(parent.Body)-1
+	}
+	return false
+}
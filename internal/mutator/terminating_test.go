@@ -0,0 +1,53 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestEndsTerminating(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{"return 1", true},
+		{"panic(err)", true},
+		{"L: x++; goto L", true},
+		{"x++", false},
+		{"if x > 0 { return 1 } else { return 0 }", true},
+		{"if x > 0 { return 1 }", false},
+		{"for {}", true},
+		{"for { break }", false},
+		{"for { for { break } }", true},
+		{"L: for { for { break L } }", false},
+		{"for x > 0 {}", false},
+		{"for range xs {}", false},
+		{"switch x { case 1: return 1; default: return 0 }", true},
+		{"switch x { case 1: return 1 }", false},
+		{"switch x { case 1: fallthrough; default: panic(x) }", true},
+		{"switch x { case 1: break; default: return 0 }", false},
+		{"L: switch x { case 1: for { break L }; default: return 0 }", false},
+		{"switch x { case 1: for { break }; return 1; default: return 0 }", true},
+		{"select { case <-ch: return 1 }", true},
+		{"select { case <-ch: return 1; default: }", false},
+		{"select {}", true},
+		{"{ return 1 }", true},
+		{"return 1;;", true},
+		{"func() { for { break } }(); for {}", true},
+	}
+
+	for _, tt := range tests {
+		src := "package p\nfunc f() int {\n" + tt.body + "\n}"
+		file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.body, err)
+		}
+		body := file.Decls[0].(*ast.FuncDecl).Body
+
+		if got := endsTerminating(body.List); got != tt.want {
+			t.Errorf("endsTerminating(%s) = %t, want %t", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package example
+
+func names(users []string) []string {
+	var found []string
+	for _, name := range users {
+		found = append(found, name)
+	}
+	found = append(found)
+	return append(found, "admin", "root")
+}
+
+type index struct {
+	byKey map[string][]int
+	all   []int
+}
+
+func (x *index) add(key string, ids ...int) {
+	x.byKey[key] = append(x.byKey[key], ids...)
+	x.all = append(x.all, ids...)
+}
+
+// the slice would be evaluated more than once, left alone
+func prefixed(users []string) []string {
+	return append([]string{"root"}, users...)
+}
+
+// append is shadowed, the builtin isn't called
+func shadowed(append func([]int, int) []int) []int {
+	return append(nil, 1)
+}
@@ -0,0 +1,27 @@
+package example
+
+type event struct{}
+
+func publish(events chan<- event, e event) int {
+	dropped := 0
+	select {
+	case events <- e:
+	default:
+		dropped++
+	}
+	return dropped
+}
+
+// only a default clause, left alone
+func nothing() {
+	select {
+	default:
+	}
+}
+
+func receive(in <-chan int) int {
+	select {
+	case v := <-in:
+		return v
+	}
+}
@@ -0,0 +1,16 @@
+package example
+
+func even(n int) bool {
+	return n%2 == 0
+}
+
+func flags(ok bool) (bool, int, bool) {
+	if ok {
+		return true, 1, false
+	}
+	return !ok, 0, ok
+}
+
+func check(valid func() bool) bool {
+	return valid()
+}
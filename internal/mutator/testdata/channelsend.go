@@ -0,0 +1,17 @@
+package example
+
+type result struct{}
+
+func produce(results chan<- result, r result) {
+	results <- r
+}
+
+// sends of select cases are left alone
+func offer(results chan<- result, r result) bool {
+	select {
+	case results <- r:
+		return true
+	default:
+		return false
+	}
+}
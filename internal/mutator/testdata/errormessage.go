@@ -0,0 +1,20 @@
+package example
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errNotFound = errors.New("not found")
+
+func lookup(key string) error {
+	if key == "" {
+		return fmt.Errorf(`empty key`)
+	}
+	return fmt.Errorf("lookup %s: %w", key, errNotFound)
+}
+
+// not a literal, left alone
+func wrap(msg string) error {
+	return errors.New(msg)
+}
@@ -0,0 +1,19 @@
+package example
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errEmpty = errors.New("empty")
+
+func parse(s string) (*int, error) {
+	if s == "" {
+		return nil, errEmpty
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
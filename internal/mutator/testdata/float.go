@@ -0,0 +1,19 @@
+package example
+
+import "math"
+
+const half = 1.0 / 2
+
+func progress(done, total float64) float64 {
+	ratio := done / total
+	return ratio - half
+}
+
+func delta(a, b float64) float64 {
+	return math.Abs(a - b)
+}
+
+// integers are left alone
+func count(a, b int) int {
+	return a / b
+}
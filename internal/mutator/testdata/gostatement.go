@@ -0,0 +1,12 @@
+package example
+
+type sink struct{}
+
+func (s *sink) flush(batch []string) {}
+
+func (s *sink) write(batch []string) {
+	go s.flush(batch)
+	go func() {
+		s.flush(nil)
+	}()
+}
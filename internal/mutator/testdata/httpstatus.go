@@ -0,0 +1,41 @@
+package example
+
+import "net/http"
+
+func create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func request(url string) (*http.Request, error) {
+	return http.NewRequest("DELETE", url, nil)
+}
+
+func fetch(client *http.Client, req *http.Request) (bool, error) {
+	req.Method = "HEAD"
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	return resp.StatusCode == 200, nil
+}
+
+func route(w http.ResponseWriter, r *http.Request) {
+	// the replacements may be other cases of the switch
+	switch r.Method {
+	case "GET":
+	case "POST", http.MethodPut:
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+// not a status or a method, left alone
+var client = http.DefaultClient
+
+var verb = "GET"
+
+var retries = 404
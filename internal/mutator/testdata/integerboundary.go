@@ -0,0 +1,14 @@
+package example
+
+var buffer [16]byte
+
+func limit(items []string) bool {
+	if len(items) > 10 {
+		return true
+	}
+	return false
+}
+
+func index(n uint) uint {
+	return n + 0
+}
@@ -0,0 +1,17 @@
+package example
+
+func values(vs []int) func(yield func(int) bool) {
+	return func(yield func(int) bool) {
+		for _, v := range vs {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func once(v int) func(yield func(int) bool) {
+	return func(yield func(int) bool) {
+		yield(v)
+	}
+}
@@ -0,0 +1,20 @@
+package example
+
+func sum(items []int) int {
+	total := 0
+	for i := 0; i < len(items); i++ {
+		total += items[i]
+	}
+	for total > 100 {
+		total /= 2
+	}
+	// without a condition, range and false loops are left alone
+	for {
+		break
+	}
+	for range items {
+	}
+	for false {
+	}
+	return total
+}
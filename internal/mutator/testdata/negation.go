@@ -0,0 +1,13 @@
+package example
+
+type store struct{}
+
+func (store) Put(key, value string, expired bool) {}
+
+func save(cache store, key, value string, expired bool) bool {
+	cache.Put(key, value, !expired)
+	fresh := len(value) > 0
+	var valid = fresh && key != ""
+	_ = bool(valid)
+	return !fresh
+}
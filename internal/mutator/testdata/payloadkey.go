@@ -0,0 +1,20 @@
+package example
+
+type user struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty" yaml:"name"`
+	Token string `json:"-"`
+	Email string
+}
+
+func render(id string) map[string]any {
+	payload := map[string]any{"id": id}
+	return payload
+}
+
+func legacy() map[string]interface{} {
+	return map[string]interface{}{`kind`: "user", "version": 2}
+}
+
+// other maps are left alone
+var counts = map[string]int{"id": 1}
@@ -0,0 +1,59 @@
+package example
+
+type List struct {
+	len int
+}
+
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return l.len
+}
+
+func (l *List) Empty() bool {
+	if nil == l {
+		return true
+	}
+	return l.len == 0
+}
+
+// value receivers and later checks are left alone
+func (l List) Cap() int {
+	return l.len
+}
+
+func (l *List) Reset() {
+	l.len = 0
+	if l == nil {
+		return
+	}
+}
+
+// the method ends with a return after the guard
+func (l *List) Last() int {
+	if l == nil {
+		return -1
+	}
+	for {
+		if l.len > 0 {
+			break
+		}
+		l.len++
+	}
+	panicIfEmpty(l)
+	l.len--
+	return l.len
+}
+
+func panicIfEmpty(*List) {}
+
+// a method ending with an endless loop still terminates
+func (l *List) Drain() int {
+	if l == nil {
+		return 0
+	}
+	for {
+		l.len--
+	}
+}
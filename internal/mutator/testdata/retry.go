@@ -0,0 +1,28 @@
+package example
+
+import "time"
+
+const maxRetries = 5
+
+type config struct {
+	Attempts int
+}
+
+var defaults = config{Attempts: 3}
+
+func fetch(get func() error) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		err = get()
+		if err == nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return err
+}
+
+func tries() int {
+	tries := 2
+	return tries
+}
@@ -0,0 +1,17 @@
+package example
+
+func total(items []string, price int) int {
+	return len(items) * price
+}
+
+func one() int {
+	return 1
+}
+
+func size(b []byte) uint {
+	return uint(len(b))
+}
+
+func ratio() float64 {
+	return 0.5
+}
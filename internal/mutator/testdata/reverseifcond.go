@@ -0,0 +1,12 @@
+package example
+
+func sign(x int) int {
+	if x > 0 {
+		return 1
+	}
+	// not a binary expression, left alone
+	if negative := x < 0; negative {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package example
+
+type job struct{}
+
+func submit(jobs chan<- job, job job) {
+	select {
+	case jobs <- job:
+	}
+}
+
+// already non-blocking, left alone
+func trySubmit(jobs chan<- job, job job) {
+	select {
+	case jobs <- job:
+	default:
+	}
+}
+
+// the function wouldn't end with a return, left alone
+func receive(jobs <-chan job, done <-chan struct{}) (job, bool) {
+	select {
+	case j := <-jobs:
+		return j, true
+	case <-done:
+		return job{}, false
+	}
+}
+
+// a loop body ends no function
+func wait(jobs <-chan job, done <-chan struct{}) bool {
+	for {
+		select {
+		case <-jobs:
+			return true
+		case <-done:
+			return false
+		}
+	}
+}
+
+func first(jobs <-chan job, done <-chan struct{}) bool {
+	switch {
+	case jobs == nil:
+		select {
+		case <-done:
+			return false
+		}
+	default:
+		return true
+	}
+}
@@ -0,0 +1,8 @@
+package example
+
+func parse(line string, start, end int) (string, string, string) {
+	header := line[start:end]
+	body := line[end:]
+	trailer := line[:len(line)-1]
+	return header, body, trailer
+}
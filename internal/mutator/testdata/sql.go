@@ -0,0 +1,14 @@
+package example
+
+const byID = "SELECT name FROM users WHERE id = $1 ORDER BY name ASC"
+
+const update = `UPDATE users SET name = $1 WHERE id = $2`
+
+const list = "select id from users order by id desc limit 10"
+
+// not queries, left alone
+const greeting = "select a greeting"
+
+type row struct {
+	Name string `db:"SELECT name FROM users"`
+}
@@ -0,0 +1,24 @@
+package example
+
+import "sync"
+
+type cache struct {
+	entries map[string]string
+	seen    sync.Map
+	last    string
+	hits    int
+}
+
+func (c *cache) invalidate(key string) {
+	delete(c.entries, key)
+	c.seen.Delete(key)
+	c.last = ""
+	c.hits = 0
+	// not zero values, left alone
+	c.hits = 1
+}
+
+func (c *cache) reset() {
+	clear(c.entries)
+	c.entries = map[string]string{}
+}
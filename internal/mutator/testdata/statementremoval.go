@@ -0,0 +1,42 @@
+package example
+
+import (
+	"log"
+	"os"
+)
+
+type store struct{}
+
+func (store) Invalidate(key string) {}
+
+func remove(cache store, key string) {
+	cache.Invalidate(key)
+	log.Printf("removed %s", key)
+	if key == "" {
+		os.Exit(1)
+	}
+	panic("unreachable")
+}
+
+type service struct {
+	cache  store
+	logger interface{ Info(string) }
+}
+
+// the call holding the only use of evicted is left alone, as evicted
+// wouldn't be used anymore, parameters may go unused
+func (s *service) evict(key string) {
+	evicted := key + "/"
+	s.cache.Invalidate(evicted)
+	s.logger.Info(key)
+	s.cache.Invalidate(key)
+}
+
+func (s *service) purge(keys []string) {
+	for _, key := range keys {
+		s.cache.Invalidate(key)
+	}
+	last := keys[len(keys)-1]
+	s.cache.Invalidate(last)
+	log.Println(last)
+}
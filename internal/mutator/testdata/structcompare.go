@@ -0,0 +1,28 @@
+package example
+
+type user struct {
+	ID   int
+	Name string
+	_    int
+}
+
+// not structs, left alone
+func equal(a, b int) bool {
+	return a == b
+}
+
+func same(got, want user) bool {
+	if got == want {
+		return true
+	}
+	return got != user{}
+}
+
+type id struct {
+	value string
+}
+
+// with its only field ignored, any two ids are equal
+func sameID(a, b id) bool {
+	return a == b
+}
@@ -0,0 +1,19 @@
+package example
+
+func search(lo, hi int) int {
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		if lo >= mid {
+			return mid
+		}
+		lo = mid
+	}
+	if lo < hi {
+		return lo
+	}
+	// the same operands and equality are left alone
+	if lo <= lo || lo == hi {
+		return lo
+	}
+	return hi
+}
@@ -0,0 +1,54 @@
+package example
+
+import "os"
+
+type connection struct{}
+
+func (connection) Close() {}
+
+type state int
+
+const (
+	StateOpen state = iota
+	StateClosed
+)
+
+func next(s state, conn connection) state {
+	switch s {
+	case StateOpen:
+		conn.Close()
+		return StateClosed
+	case StateClosed:
+		return StateOpen
+	default:
+		conn.Close()
+	}
+	return s
+}
+
+func parse(args []string) int {
+	n := 0
+loop:
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			n++
+			break loop
+		case "-h":
+			usage()
+			os.Exit(2)
+		case "-v":
+			v := len(arg)
+			return v
+		case "-x":
+			if n > 0 {
+				return n
+			} else {
+				panic(arg)
+			}
+		}
+	}
+	return n
+}
+
+func usage() {}
@@ -0,0 +1,57 @@
+package example
+
+import "fmt"
+
+func check(kind int) error {
+	switch kind {
+	case 0, 1:
+	default:
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+	return nil
+}
+
+func describe(v any) string {
+	var s string
+	switch v.(type) {
+	case string:
+		s = "string"
+	default:
+		s = "other"
+	}
+	return s
+}
+
+// every clause returns at the end of the function, left alone
+func kindName(kind int) string {
+	switch kind {
+	case 0:
+		return "zero"
+	default:
+		return "other"
+	}
+}
+
+// as for labeled switches, whose breaks may refer to them
+func kindCode(kind int) int {
+outer:
+	switch kind {
+	case 0:
+		for {
+			break outer
+		}
+	default:
+		return 1
+	}
+	return 0
+}
+
+func kindLabel(kind int) string {
+outer:
+	switch kind {
+	case 0:
+		return "zero"
+	default:
+		goto outer
+	}
+}
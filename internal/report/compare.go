@@ -0,0 +1,110 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Change is a mutant whose status differs between two runs. Before is
+// empty for added mutants and After for removed ones.
+type Change struct {
+	ID     string `json:"id"`
+	Before Status `json:"before,omitempty"`
+	After  Status `json:"after,omitempty"`
+}
+
+// Comparison is the difference between two runs, typically before and
+// after changing the tests. Mutants are matched by ID and mutator version:
+// a mutator that changed makes different mutants, so they show up as
+// removed and added instead of with a misleading status change.
+type Comparison struct {
+	NewlyKilled    []Change `json:"newlyKilled"`
+	NewlySurviving []Change `json:"newlySurviving"`
+	Added          []Change `json:"added"`
+	Removed        []Change `json:"removed"`
+}
+
+// Compare returns the mutants of after whose status changed since before.
+func Compare(before, after Document) Comparison {
+	beforeMutants := mutants(before)
+	afterMutants := mutants(after)
+
+	var c Comparison
+	for key, a := range afterMutants {
+		b, ok := beforeMutants[key]
+		change := Change{ID: a.ID, Before: b.Status, After: a.Status}
+		switch {
+		case !ok:
+			c.Added = append(c.Added, change)
+		case a.Status == Killed && b.Status != Killed:
+			c.NewlyKilled = append(c.NewlyKilled, change)
+		case a.Status == Survived && b.Status != Survived:
+			c.NewlySurviving = append(c.NewlySurviving, change)
+		}
+	}
+
+	for key, b := range beforeMutants {
+		if _, ok := afterMutants[key]; !ok {
+			c.Removed = append(c.Removed, Change{ID: b.ID, Before: b.Status})
+		}
+	}
+
+	for _, changes := range [][]Change{c.NewlyKilled, c.NewlySurviving, c.Added, c.Removed} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].ID < changes[j].ID
+		})
+	}
+
+	return c
+}
+
+// mutants indexes the mutants of a run by ID and mutator version.
+func mutants(doc Document) map[string]Mutant {
+	s := map[string]Mutant{}
+	for _, r := range doc.Results {
+		for _, m := range r.Mutants {
+			s[m.ID+"@"+m.Version] = m
+		}
+	}
+	return s
+}
+
+// ReadDocument loads a report written by the JSON sink. Reports of runs
+// that didn't finish are rejected, as missing mutants would show up as
+// removed.
+func ReadDocument(filename string) (Document, error) {
+	doc, err := ReadLiveDocument(filename)
+	if err != nil {
+		return doc, err
+	}
+
+	if doc.InProgress {
+		return doc, fmt.Errorf("report %s is incomplete, the run didn't finish", filename)
+	}
+
+	return doc, nil
+}
+
+// ReadLiveDocument loads a report written by the JSON sink, which may be
+// of a run still in progress.
+func ReadLiveDocument(filename string) (Document, error) {
+	var doc Document
+
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return doc, err
+	}
+
+	err = json.Unmarshal(bytes, &doc)
+	if err != nil {
+		return doc, fmt.Errorf("invalid report %s: %s", filename, err)
+	}
+
+	if doc.SchemaVersion > SchemaVersion {
+		return doc, fmt.Errorf("report %s has schema version %d, newer than the %d this selene reads: upgrade selene, or convert the report with selene report convert --to %d using the one that wrote it", filename, doc.SchemaVersion, SchemaVersion, SchemaVersion)
+	}
+
+	return doc, nil
+}
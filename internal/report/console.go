@@ -0,0 +1,296 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Formats of the console report.
+const (
+	// Verbose prints every mutant in a format similar to go test -v,
+	// with survivors followed by the commands reproducing them.
+	Verbose = "verbose"
+	// Condensed prints a line per package and per survivor, and a
+	// summary of the whole run at the end, similar to gotestsum.
+	Condensed = "condensed"
+	// Dots prints a character per mutant as it finishes, and the
+	// survivors and a summary of the whole run at the end, keeping CI
+	// logs small.
+	Dots = "dots"
+)
+
+// ConsoleFormats are the formats NewConsole accepts.
+var ConsoleFormats = []string{Verbose, Condensed, Dots}
+
+// dotsPerLine is how many mutants the dots format prints before the count
+// of those finished so far.
+const dotsPerLine = 50
+
+type console struct {
+	w      io.Writer
+	format string
+
+	// totals of the run, for the summary of the condensed format
+	packages int
+	counts   map[Status]int
+	higher   map[Status]int // of the higher-order mutants, scored apart
+
+	// mutants of the current package printed by the dots format, and
+	// the survivors of the run, listed at the end
+	printed   int
+	survivors []Mutant
+}
+
+// NewConsole returns a sink printing results in one of ConsoleFormats.
+func NewConsole(w io.Writer, format string) Sink {
+	return &console{w: w, format: format, counts: map[Status]int{}, higher: map[Status]int{}}
+}
+
+func (c *console) Write(r Result) error {
+	c.packages++
+	for _, m := range r.Mutants {
+		c.counts[m.Status]++
+	}
+	for _, m := range r.HigherOrder {
+		c.higher[m.Status]++
+	}
+
+	switch c.format {
+	case Condensed:
+		c.writeCondensed(r)
+		return nil
+	case Dots:
+		c.writeDots(r)
+		if counts := summary(r.Mutants); counts != "" {
+			fmt.Fprintf(c.w, " %s (%s)\n", r.Dir, counts)
+		}
+		for _, l := range r.Lines() {
+			for _, m := range l.Mutants {
+				if m.Status == Survived {
+					c.survivors = append(c.survivors, m)
+				}
+			}
+		}
+		c.printed = 0
+		return nil
+	}
+
+	fmt.Fprintf(c.w, "go version %s\n", r.GoVersion)
+	if len(r.TestedBy) > 0 {
+		fmt.Fprintf(c.w, "--- NOTE: no tests of its own, mutants are tested by %s\n", strings.Join(r.TestedBy, ", "))
+	}
+	for _, s := range r.Skipped {
+		fmt.Fprintf(c.w, "--- SKIP: %s (%s)\n", s.File, s.Reason)
+	}
+	for _, t := range r.AssertionFree {
+		fmt.Fprintf(c.w, "--- WARN: %s has no assertions, it can't kill any mutant\n", t)
+	}
+	for _, s := range r.Focused {
+		fmt.Fprintf(c.w, "--- WARN: %s has focused specs, the others can't kill any mutant\n", s)
+	}
+	if panics := r.Panics(); panics > 0 {
+		fmt.Fprintf(c.w, "--- WARN: %d of %d killed mutants were only killed by panics, the tests may miss subtler changes\n", panics, r.Count(Killed))
+	}
+	// mutants of the same line are grouped like subtests
+	for _, l := range r.Lines() {
+		if len(l.Mutants) == 1 {
+			fmt.Fprintf(c.w, "=== RUN   %s\n", l.Mutants[0].ID)
+			c.writeMutant(l.Mutants[0], "")
+			continue
+		}
+
+		fmt.Fprintf(c.w, "=== LINE  %s\n", l.Position())
+		for _, m := range l.Mutants {
+			fmt.Fprintf(c.w, "=== RUN   %s\n", m.ID)
+		}
+		fmt.Fprintf(c.w, "--- LINE: %s (%s)\n", l.Position(), l.Summary())
+		for _, m := range l.Mutants {
+			c.writeMutant(m, "    ")
+		}
+	}
+	// a test killing one survivor of a cluster likely kills the others
+	for _, cl := range r.Clusters() {
+		fmt.Fprintf(c.w, "--- CLUSTER: %d survivors %s\n", len(cl.Mutants), cl.Reason)
+		for _, m := range cl.Mutants {
+			fmt.Fprintf(c.w, "    %s\n", m.ID)
+		}
+	}
+	// pairs of mutants tested together with --order 2, apart from the
+	// others as they aren't scored
+	if len(r.HigherOrder) > 0 {
+		fmt.Fprintf(c.w, "--- HIGHER ORDER: %d pairs (%s), experimental, not scored\n", len(r.HigherOrder), summary(r.HigherOrder))
+		for _, m := range r.HigherOrder {
+			if m.Status == Survived {
+				fmt.Fprintf(c.w, "    --- SURVIVED: %s (%0.2fs)\n", m.ID, m.Elapsed)
+				fmt.Fprintf(c.w, "        %s\n", m.GoTest)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *console) writeMutant(m Mutant, indent string) {
+	switch m.Status {
+	case Killed:
+		by := strings.Join(m.KilledBy, ", ")
+		if notes := m.Notes(); notes != "" {
+			by += " (" + notes + ")"
+		}
+		fmt.Fprintf(c.w, "%s--- KILLED: %s (%0.2fs) by %s\n", indent, m.ID, m.Elapsed, by)
+	case Survived:
+		line := fmt.Sprintf("%s--- SURVIVED: %s (%0.2fs)", indent, m.ID, m.Elapsed)
+		if age := m.Age(); age != "" {
+			line += ", surviving " + age
+		}
+		// what changed, so survivors make sense without opening the file
+		if change := m.Change(); change != "" {
+			line += ": " + change
+		}
+		fmt.Fprintln(c.w, line)
+		fmt.Fprintf(c.w, "%s    %s\n", indent, m.Repro)
+		fmt.Fprintf(c.w, "%s    %s\n", indent, m.GoTest)
+	default:
+		fmt.Fprintf(c.w, "%s--- BUILD FAILED: %s\n", indent, m.ID)
+	}
+}
+
+// writeCondensed prints the package like go test without -v, followed by
+// its warnings and survivors, a line each.
+func (c *console) writeCondensed(r Result) {
+	status := "ok  "
+	if r.Count(Survived) > 0 {
+		status = "FAIL"
+	}
+	counts := summary(r.Mutants)
+	if counts == "" {
+		counts = "no mutants"
+	}
+	fmt.Fprintf(c.w, "%s\t%s\t(%s)\n", status, r.Dir, counts)
+
+	if len(r.TestedBy) > 0 {
+		fmt.Fprintf(c.w, "    NOTE tested by %s\n", strings.Join(r.TestedBy, ", "))
+	}
+	for _, s := range r.Skipped {
+		fmt.Fprintf(c.w, "    SKIP %s (%s)\n", s.File, s.Reason)
+	}
+	for _, t := range r.AssertionFree {
+		fmt.Fprintf(c.w, "    WARN %s has no assertions\n", t)
+	}
+	for _, s := range r.Focused {
+		fmt.Fprintf(c.w, "    WARN %s has focused specs\n", s)
+	}
+	for _, l := range r.Lines() {
+		for _, m := range l.Mutants {
+			if m.Status != Survived {
+				continue
+			}
+			line := "    SURVIVED " + m.ID
+			if change := m.Change(); change != "" {
+				line += ": " + change
+			}
+			fmt.Fprintln(c.w, line)
+		}
+	}
+	if len(r.HigherOrder) > 0 {
+		fmt.Fprintf(c.w, "    HIGHER ORDER %d pairs (%s), not scored\n", len(r.HigherOrder), summary(r.HigherOrder))
+		for _, m := range r.HigherOrder {
+			if m.Status == Survived {
+				fmt.Fprintf(c.w, "    SURVIVED %s\n", m.ID)
+			}
+		}
+	}
+}
+
+// Progress prints the mutants finished since the last call in the dots
+// format, and nothing in the others.
+func (c *console) Progress(r Result) error {
+	if c.format == Dots {
+		c.writeDots(r)
+	}
+	return nil
+}
+
+// writeDots prints a character for each mutant of r not printed yet: .
+// if killed, T if killed by a timeout, S if survived and B if it didn't
+// build. Every dotsPerLine mutants it prints how many have finished and
+// survived so far.
+func (c *console) writeDots(r Result) {
+	for ; c.printed < len(r.Mutants); c.printed++ {
+		m := r.Mutants[c.printed]
+		switch {
+		case m.Status == Killed && m.Cause == TimeoutCause:
+			fmt.Fprint(c.w, "T")
+		case m.Status == Killed:
+			fmt.Fprint(c.w, ".")
+		case m.Status == Survived:
+			fmt.Fprint(c.w, "S")
+		default:
+			fmt.Fprint(c.w, "B")
+		}
+
+		if (c.printed+1)%dotsPerLine == 0 {
+			fmt.Fprintf(c.w, " %d, %d survived\n", c.printed+1, Result{Mutants: r.Mutants[:c.printed+1]}.Count(Survived))
+		}
+	}
+}
+
+// writeSummary prints the totals of the run in a box.
+func (c *console) writeSummary() {
+	total := c.counts[Killed] + c.counts[Survived] + c.counts[BuildFailed]
+	packages := "packages"
+	if c.packages == 1 {
+		packages = "package"
+	}
+	lines := []string{fmt.Sprintf("%d mutants in %d %s", total, c.packages, packages)}
+	for _, status := range []Status{Killed, Survived, BuildFailed} {
+		lines = append(lines, fmt.Sprintf("%6d %s", c.counts[status], status))
+	}
+	if tested := c.counts[Killed] + c.counts[Survived]; tested > 0 {
+		lines = append(lines, fmt.Sprintf("mutation score %.1f%%", float64(c.counts[Killed])/float64(tested)*100))
+	}
+	if tested := c.higher[Killed] + c.higher[Survived]; tested > 0 {
+		lines = append(lines, fmt.Sprintf("higher-order score %.1f%% (experimental)", float64(c.higher[Killed])/float64(tested)*100))
+	}
+
+	width := 0
+	for _, l := range lines {
+		width = max(width, len(l))
+	}
+	border := "+" + strings.Repeat("-", width+2) + "+"
+	fmt.Fprintln(c.w, border)
+	for _, l := range lines {
+		fmt.Fprintf(c.w, "| %-*s |\n", width, l)
+	}
+	fmt.Fprintln(c.w, border)
+}
+
+func (c *console) Close(m Metadata) error {
+	switch c.format {
+	case Condensed:
+		c.writeSummary()
+	case Dots:
+		for _, m := range c.survivors {
+			line := "SURVIVED " + m.ID
+			if change := m.Change(); change != "" {
+				line += ": " + change
+			}
+			fmt.Fprintln(c.w, line)
+		}
+		c.writeSummary()
+	}
+
+	info := []string{"selene " + m.Version, m.Mode + " mode", "mutators: " + strings.Join(m.Mutators, ", ")}
+	if m.Git.Commit != "" {
+		commit := m.Git.Commit
+		if m.Git.Dirty {
+			commit += " (dirty)"
+		}
+		info = append(info, "git: "+commit)
+	}
+	info = append(info, m.Duration.Round(time.Millisecond).String())
+
+	fmt.Fprintln(c.w, strings.Join(info, ", "))
+	return nil
+}
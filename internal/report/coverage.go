@@ -0,0 +1,281 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Coverage classes of source lines, from the baseline coverage profile
+// and the mutants of the line.
+const (
+	lineKilled    = "killed"    // covered, and all its mutants killed
+	lineSurvived  = "survived"  // covered, but some mutant survived
+	lineUncovered = "uncovered" // has mutants, but no test runs it
+	lineCovered   = "covered"   // run by some test, without mutants
+	lineMissed    = "missed"    // not run by any test, without mutants
+)
+
+var coveragePage = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>selene coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { line-height: 1.3; }
+.killed { background: #c8f0c8; }
+.survived { background: #f8c8c8; }
+.uncovered { background: #f0e0a0; }
+.covered { color: #060; }
+.missed { color: #888; }
+</style>
+</head>
+<body>
+<h1>selene coverage</h1>
+<p>Lines with mutants are <span class="killed">covered and killed</span>, <span class="survived">covered but survived</span> or <span class="uncovered">not covered</span>. Other lines are <span class="covered">covered</span> or <span class="missed">not covered</span> by the tests.</p>
+{{range .}}
+<h2>{{.Name}}</h2>
+<p>{{.Killed}} lines killed, {{.Survived}} survived, {{.Uncovered}} uncovered</p>
+<pre>{{range .Lines}}<span class="{{.Class}}">{{printf "%5d" .Number}}  {{.Text}}</span>
+{{end}}</pre>
+{{else}}
+<p>No coverage was measured, as happens when the baseline doesn't run.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type coverageDir struct {
+	dir     string
+	results []Result
+}
+
+// NewCoverage returns a sink writing to dir an index.html page showing
+// the source of the mutated files with both the coverage of the baseline
+// and the outcome of the mutants of each line, and a mutation.cover
+// profile for go tool cover, where lines with mutants are blocks counted
+// as run only if all their mutants were killed.
+func NewCoverage(dir string) Sink {
+	return &coverageDir{dir: dir}
+}
+
+func (c *coverageDir) Write(r Result) error {
+	c.results = append(c.results, r)
+	return nil
+}
+
+func (c *coverageDir) Close(Metadata) error {
+	var files []coverageFile
+	for _, r := range c.results {
+		fs, err := coverageFiles(r)
+		if err != nil {
+			return err
+		}
+		files = append(files, fs...)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	err := os.MkdirAll(c.dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(filepath.Join(c.dir, "index.html"), func(w io.Writer) error {
+		return coveragePage.Execute(w, files)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(c.dir, "mutation.cover"), func(w io.Writer) error {
+		fmt.Fprintln(w, "mode: set")
+		for _, f := range files {
+			for _, l := range f.Lines {
+				if l.Class != lineKilled && l.Class != lineSurvived && l.Class != lineUncovered {
+					continue
+				}
+				count := 0
+				if l.Class == lineKilled {
+					count = 1
+				}
+				fmt.Fprintf(w, "%s:%d.1,%d.%d %d %d\n", f.ImportPath, l.Number, l.Number, len(l.Text)+1, l.Mutants, count)
+			}
+		}
+		return nil
+	})
+}
+
+// coverageFile is a mutated file annotated with coverage.
+type coverageFile struct {
+	Name       string // as in mutant IDs
+	ImportPath string // as in coverage profiles
+	Lines      []coverageLine
+
+	Killed, Survived, Uncovered int // lines by class
+}
+
+type coverageLine struct {
+	Number  int
+	Text    string
+	Class   string
+	Mutants int // killed or survived
+}
+
+// coverageFiles annotates the mutated files of a result with the coverage
+// profile of its baseline. Results without one are left out.
+func coverageFiles(r Result) ([]coverageFile, error) {
+	if r.CoverProfile == "" {
+		return nil, nil
+	}
+
+	blocks, err := readCoverProfile(r.CoverProfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage of %s: %s", r.Dir, err)
+	}
+
+	var files []coverageFile
+	for _, mutants := range groupByFile(r.Mutants) {
+		filename := mutants[0].File
+		source, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		// the position of a line starts with the file as in IDs
+		f := coverageFile{Name: Line{File: filename, Mutants: mutants}.Position()}
+		f.Name = f.Name[:strings.LastIndex(f.Name, ":")]
+
+		// profiles name files by import path, and only cover the
+		// package of the result
+		covered := map[int]bool{}
+		for _, b := range blocks {
+			if path.Base(b.file) != filepath.Base(filename) {
+				continue
+			}
+			f.ImportPath = b.file
+			for line := b.start; line <= b.end; line++ {
+				covered[line] = covered[line] || b.count > 0
+			}
+		}
+		if f.ImportPath == "" {
+			continue
+		}
+
+		byLine := map[int][]Mutant{}
+		for _, m := range mutants {
+			if m.Status == Killed || m.Status == Survived {
+				byLine[m.Line] = append(byLine[m.Line], m)
+			}
+		}
+
+		for i, text := range strings.Split(strings.TrimSuffix(string(source), "\n"), "\n") {
+			l := coverageLine{Number: i + 1, Text: strings.TrimSuffix(text, "\r"), Mutants: len(byLine[i+1])}
+			isCovered, instrumented := covered[l.Number]
+			switch {
+			case l.Mutants > 0 && !isCovered:
+				l.Class = lineUncovered
+				f.Uncovered++
+			case l.Mutants > 0 && hasStatus(byLine[l.Number], Survived):
+				l.Class = lineSurvived
+				f.Survived++
+			case l.Mutants > 0:
+				l.Class = lineKilled
+				f.Killed++
+			case isCovered:
+				l.Class = lineCovered
+			case instrumented:
+				l.Class = lineMissed
+			}
+			f.Lines = append(f.Lines, l)
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// groupByFile groups mutants by file, in order of first appearance.
+func groupByFile(mutants []Mutant) [][]Mutant {
+	var groups [][]Mutant
+	index := map[string]int{}
+	for _, m := range mutants {
+		i, ok := index[m.File]
+		if !ok {
+			i = len(groups)
+			index[m.File] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], m)
+	}
+	return groups
+}
+
+func hasStatus(mutants []Mutant, s Status) bool {
+	for _, m := range mutants {
+		if m.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// coverBlock is a block of a go coverage profile, by lines.
+type coverBlock struct {
+	file       string
+	start, end int
+	count      int
+}
+
+// readCoverProfile reads the blocks of a go test -coverprofile file.
+func readCoverProfile(filename string) ([]coverBlock, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var blocks []coverBlock
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "mode:") || line == "" {
+			continue
+		}
+
+		// file:startLine.startCol,endLine.endCol numStmt count
+		file, rest, ok := strings.Cut(line, ":")
+		fields := strings.Fields(rest)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		from, to, _ := strings.Cut(fields[0], ",")
+		from, _, _ = strings.Cut(from, ".")
+		to, _, _ = strings.Cut(to, ".")
+
+		b := coverBlock{file: file}
+		b.start, err = strconv.Atoi(from)
+		if err == nil {
+			b.end, err = strconv.Atoi(to)
+		}
+		if err == nil {
+			b.count, err = strconv.Atoi(fields[2])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		blocks = append(blocks, b)
+	}
+
+	return blocks, scanner.Err()
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCoverProfile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "c.out")
+	profile := "mode: set\nexample.com/m/pkg/a.go:3.14,5.2 1 1\nexample.com/m/pkg/a.go:7.2,7.10 1 0\n"
+	if err := os.WriteFile(filename, []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := readCoverProfile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []coverBlock{
+		{file: "example.com/m/pkg/a.go", start: 3, end: 5, count: 1},
+		{file: "example.com/m/pkg/a.go", start: 7, end: 7, count: 0},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("readCoverProfile() = %+v, want %+v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+
+	if err := os.WriteFile(filename, []byte("mode: set\nbroken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCoverProfile(filename); err == nil {
+		t.Error("readCoverProfile() of an invalid profile succeeded")
+	}
+}
+
+// Profiles name files by import path, with slashes, while mutants have
+// the paths of the platform.
+func TestCoverageFiles(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "pkg", "a.go")
+	if err := os.MkdirAll(filepath.Dir(source), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(source, []byte("package pkg\n\nfunc A(x int) bool {\n\treturn x > 0\n}\n\nfunc B() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profile := filepath.Join(dir, "c.out")
+	if err := os.WriteFile(profile, []byte("mode: set\nexample.com/m/pkg/a.go:3.20,5.2 1 1\nexample.com/m/pkg/a.go:7.11,7.12 0 0\nexample.com/m/pkg/b.go:1.1,2.2 1 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := coverageFiles(Result{
+		CoverProfile: profile,
+		Mutants: []Mutant{
+			{ID: "pkg/a.go:4:11:Negation", File: source, Line: 4, Status: Survived},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("coverageFiles() = %d files, want 1", len(files))
+	}
+
+	f := files[0]
+	if f.Name != "pkg/a.go" || f.ImportPath != "example.com/m/pkg/a.go" {
+		t.Errorf("coverage file %q of %q, want pkg/a.go of example.com/m/pkg/a.go", f.Name, f.ImportPath)
+	}
+	classes := map[int]string{3: lineCovered, 4: lineSurvived, 5: lineCovered, 7: lineMissed}
+	for _, l := range f.Lines {
+		if l.Class != classes[l.Number] {
+			t.Errorf("line %d is %q, want %q", l.Number, l.Class, classes[l.Number])
+		}
+	}
+	if f.Survived != 1 || f.Killed != 0 || f.Uncovered != 0 {
+		t.Errorf("coverage file has %d killed, %d survived, %d uncovered lines, want 0, 1, 0", f.Killed, f.Survived, f.Uncovered)
+	}
+}
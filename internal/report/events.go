@@ -0,0 +1,119 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event types, in the order they are emitted. Scan and mutant events
+// repeat for every package; mutant events of a package interleave, as
+// mutants are tested concurrently.
+const (
+	ScanStarted    = "scan-started"
+	ScanFinished   = "scan-finished"
+	MutantStarted  = "mutant-started"
+	MutantFinished = "mutant-finished"
+	RunFinished    = "run-finished"
+)
+
+// Event is a line of the event stream. Fields are only set for the event
+// types they apply to.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// scan-started and scan-finished
+	Dir     string   `json:"dir,omitempty"`
+	Files   []string `json:"files,omitempty"`   // scan-started
+	Mutants int      `json:"mutants,omitempty"` // scan-finished
+
+	// mutant-started, with the position of the mutant only, and
+	// mutant-finished, with its outcome
+	Mutant *Mutant `json:"mutant,omitempty"`
+
+	Run *RunSummary `json:"run,omitempty"` // run-finished
+}
+
+// RunSummary is the outcome of a whole run.
+type RunSummary struct {
+	Killed      int    `json:"killed"`
+	Survived    int    `json:"survived"`
+	BuildFailed int    `json:"buildFailed"`
+	ExitCode    int    `json:"exitCode"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Events writes newline-delimited JSON events as a run progresses, for
+// tools following it live. A nil *Events discards events.
+type Events struct {
+	mu     sync.Mutex
+	w      io.Writer
+	c      io.Closer
+	err    error
+	counts map[Status]int
+}
+
+// OpenEvents opens the event stream of --events: - for stdout, or a file.
+func OpenEvents(target string, stdout io.Writer) (*Events, error) {
+	if target == "-" {
+		return &Events{w: stdout, counts: map[Status]int{}}, nil
+	}
+
+	f, err := os.Create(target)
+	if err != nil {
+		return nil, err
+	}
+	return &Events{w: f, c: f, counts: map[Status]int{}}, nil
+}
+
+// Emit writes an event, stamped with the current time. Write errors are
+// kept for Close, so a broken stream doesn't stop the run. The outcomes of
+// finished mutants are counted, and the counts of run-finished filled in.
+func (e *Events) Emit(ev Event) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ev.Time = time.Now()
+	switch ev.Type {
+	case MutantFinished:
+		e.counts[ev.Mutant.Status]++
+	case RunFinished:
+		ev.Run.Killed = e.counts[Killed]
+		ev.Run.Survived = e.counts[Survived]
+		ev.Run.BuildFailed = e.counts[BuildFailed]
+	}
+
+	if e.err != nil {
+		return
+	}
+
+	b, err := json.Marshal(ev)
+	if err == nil {
+		_, err = e.w.Write(append(b, '\n'))
+	}
+	e.err = err
+}
+
+// Close closes the stream, returning the first error writing it.
+func (e *Events) Close() error {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.c != nil {
+		if err := e.c.Close(); e.err == nil {
+			e.err = err
+		}
+	}
+	return e.err
+}
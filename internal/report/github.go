@@ -0,0 +1,82 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type github struct {
+	w                io.Writer
+	workspace        string
+	killed, survived int
+}
+
+// NewGitHub returns a sink writing GitHub Actions workflow commands to w:
+// a warning annotation on the line of every survivor, and a notice with
+// the mutation score when closed. Paths are made relative to workspace,
+// the checkout of the repository, as annotations expect.
+func NewGitHub(w io.Writer, workspace string) Sink {
+	return &github{w: w, workspace: workspace}
+}
+
+func (g *github) Write(r Result) error {
+	for _, m := range r.Mutants {
+		switch m.Status {
+		case Killed:
+			g.killed++
+		case Survived:
+			g.survived++
+			g.annotate(m)
+		}
+	}
+	return nil
+}
+
+// annotate writes the warning of a survivor.
+func (g *github) annotate(m Mutant) {
+	msg := fmt.Sprintf("Mutant %s survived: no test notices this change.", m.ID)
+	if change := m.Change(); change != "" {
+		msg += "\n" + change
+	}
+	if m.Repro != "" {
+		msg += "\nReproduce with: " + m.Repro
+	}
+
+	fmt.Fprintf(g.w, "::warning file=%s,line=%d,col=%d,title=%s::%s\n",
+		escapeProperty(g.path(m.File)), m.Line, m.Column,
+		escapeProperty("Surviving mutant ("+m.Mutator+")"), escapeData(msg))
+}
+
+// path returns filename relative to the workspace, or as is if it is
+// outside of it.
+func (g *github) path(filename string) string {
+	if g.workspace == "" || !filepath.IsAbs(filename) {
+		return filepath.ToSlash(filename)
+	}
+	rel, err := filepath.Rel(g.workspace, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(filename)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (g *github) Close(Metadata) error {
+	tested := g.killed + g.survived
+	if tested == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(g.w, "::notice title=Mutation score::%s\n", escapeData(fmt.Sprintf("%d mutants, %d survived, mutation score %.1f%%", tested, g.survived, float64(g.killed)/float64(tested)*100)))
+	return err
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property of a workflow command, as its file.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
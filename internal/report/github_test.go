@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, data, property string
+	}{
+		{"plain", "plain", "plain"},
+		{"50%", "50%25", "50%25"},
+		{"a\nb\r\n", "a%0Ab%0D%0A", "a%0Ab%0D%0A"},
+		{"x: y, z", "x: y, z", "x%3A y%2C z"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeData(tt.in); got != tt.data {
+			t.Errorf("escapeData(%q) = %q, want %q", tt.in, got, tt.data)
+		}
+		if got := escapeProperty(tt.in); got != tt.property {
+			t.Errorf("escapeProperty(%q) = %q, want %q", tt.in, got, tt.property)
+		}
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewGitHub(&buf, "/src/repo")
+
+	err := sink.Write(Result{Mutants: []Mutant{
+		{ID: "a.go:3:5:Negation", File: "/src/repo/pkg/a.go", Line: 3, Column: 5, Mutator: "Negation", Status: Survived},
+		{ID: "a.go:4:2:ErrorNil", File: "/src/repo/pkg/a.go", Line: 4, Column: 2, Mutator: "ErrorNil", Status: Killed},
+		{ID: "b.go:1:1:Negation", File: "/elsewhere/b.go", Line: 1, Column: 1, Mutator: "Negation", Status: BuildFailed},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Close(Metadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "::warning file=pkg/a.go,line=3,col=5,title=Surviving mutant (Negation)::Mutant a.go:3:5:Negation survived: no test notices this change.\n" +
+		"::notice title=Mutation score::2 mutants, 1 survived, mutation score 50.0%25\n"
+	if got := buf.String(); got != want {
+		t.Errorf("github sink wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+// With the dots format on the same output, annotations still start their
+// own lines, whatever the order of the sinks.
+func TestGitHubWithDots(t *testing.T) {
+	var buf bytes.Buffer
+	sink := Multi(NewGitHub(&buf, "/src/repo"), NewConsole(&buf, Dots))
+
+	r := Result{Dir: "/src/repo/pkg", Mutants: []Mutant{
+		{ID: "a.go:3:5:Negation", File: "/src/repo/pkg/a.go", Line: 3, Column: 5, Mutator: "Negation", Status: Survived},
+		{ID: "a.go:4:2:ErrorNil", File: "/src/repo/pkg/a.go", Line: 4, Column: 2, Mutator: "ErrorNil", Status: Killed},
+	}}
+	err := sink.(Progress).Progress(Result{Dir: r.Dir, Mutants: r.Mutants[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Write(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Close(Metadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	annotations := 0
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "::") {
+			annotations++
+		} else if strings.Contains(line, "::") {
+			t.Errorf("annotation in the middle of the line %q", line)
+		}
+	}
+	if annotations != 2 {
+		t.Errorf("got %d annotations at the start of a line, want 2\n%s", annotations, buf.String())
+	}
+}
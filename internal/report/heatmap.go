@@ -0,0 +1,294 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tile is a rectangle of the heatmap: a file, or a directory around the
+// tiles of its files and subdirectories.
+type Tile struct {
+	Path       string
+	Dir        bool
+	Mutants    int // all of them, sizing the tile
+	Killed     int
+	Survived   int
+	X, Y, W, H float64
+}
+
+// SurvivalRate returns the share of the tested mutants of the tile that
+// survived, and false if none was tested.
+func (t Tile) SurvivalRate() (float64, bool) {
+	if t.Killed+t.Survived == 0 {
+		return 0, false
+	}
+	return float64(t.Survived) / float64(t.Killed+t.Survived), true
+}
+
+// Color returns the fill of the tile, from green when every mutant was
+// killed to red when they all survived, grey if none was tested.
+func (t Tile) Color() string {
+	rate, ok := t.SurvivalRate()
+	if !ok {
+		return "hsl(0, 0%, 80%)"
+	}
+	return fmt.Sprintf("hsl(%.0f, 70%%, 50%%)", 120*(1-rate))
+}
+
+// Title returns the tooltip of the tile.
+func (t Tile) Title() string {
+	rate, ok := t.SurvivalRate()
+	if !ok {
+		return fmt.Sprintf("%s: %d mutants, none tested", t.Path, t.Mutants)
+	}
+	return fmt.Sprintf("%s: %d mutants, %d survived (%.0f%%)", t.Path, t.Mutants, t.Survived, rate*100)
+}
+
+// Label returns the name shown on the tile, its base name, or nothing if
+// it doesn't fit.
+func (t Tile) Label() string {
+	name := path.Base(t.Path)
+	if t.H < 14 || float64(len(name))*7 > t.W {
+		return ""
+	}
+	return name
+}
+
+// heatmapPadding is the space between a directory and its tiles, and
+// heatmapHeader the room above them for its name.
+const (
+	heatmapPadding = 2
+	heatmapHeader  = 14
+)
+
+// treeNode is a directory or a file of the heatmap.
+type treeNode struct {
+	path     string
+	children map[string]*treeNode // nil for files
+	mutants  int
+	killed   int
+	survived int
+}
+
+// Heatmap lays out the files of a run as a squarified treemap of the given
+// size, nested by directory from the one all the files share. The area of
+// a tile is proportional to its mutants, and the survival rate tells how
+// weak its tests are.
+func Heatmap(doc Document, width, height float64) []Tile {
+	var files []string
+	byFile := map[string][]Mutant{}
+	for _, r := range doc.Results {
+		for _, m := range r.Mutants {
+			file := filepath.ToSlash(m.File)
+			if byFile[file] == nil {
+				files = append(files, file)
+			}
+			byFile[file] = append(byFile[file], m)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	prefix := commonDir(files)
+	root := &treeNode{path: strings.TrimSuffix(prefix, "/"), children: map[string]*treeNode{}}
+	if root.path == "" {
+		root.path = "."
+	}
+	for _, file := range files {
+		rel := strings.TrimPrefix(file, prefix)
+		node := root
+		parts := strings.Split(rel, "/")
+		for i, part := range parts {
+			child := node.children[part]
+			if child == nil {
+				child = &treeNode{path: path.Join(node.path, part)}
+				if i < len(parts)-1 {
+					child.children = map[string]*treeNode{}
+				}
+				node.children[part] = child
+			}
+			node = child
+		}
+		for _, m := range byFile[file] {
+			node.mutants++
+			switch m.Status {
+			case Killed:
+				node.killed++
+			case Survived:
+				node.survived++
+			}
+		}
+	}
+	root.total()
+
+	var tiles []Tile
+	place(root, 0, 0, width, height, &tiles)
+	return tiles
+}
+
+// commonDir returns the directory shared by all files, with a trailing
+// slash, or nothing if they share none.
+func commonDir(files []string) string {
+	dir := path.Dir(files[0])
+	for _, file := range files[1:] {
+		for dir != "." && dir != "/" && !strings.HasPrefix(file, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+
+	switch dir {
+	case ".":
+		return ""
+	case "/":
+		return dir
+	}
+	return dir + "/"
+}
+
+// total sums the mutants of the files under a directory.
+func (n *treeNode) total() {
+	for _, child := range n.children {
+		if child.children != nil {
+			child.total()
+		}
+		n.mutants += child.mutants
+		n.killed += child.killed
+		n.survived += child.survived
+	}
+}
+
+// place appends the tile of n in the given rectangle, and those of its
+// children within it.
+func place(n *treeNode, x, y, w, h float64, tiles *[]Tile) {
+	*tiles = append(*tiles, Tile{
+		Path:     n.path,
+		Dir:      n.children != nil,
+		Mutants:  n.mutants,
+		Killed:   n.killed,
+		Survived: n.survived,
+		X:        x, Y: y, W: w, H: h,
+	})
+	if n.children == nil {
+		return
+	}
+
+	var children []*treeNode
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].mutants != children[j].mutants {
+			return children[i].mutants > children[j].mutants
+		}
+		return children[i].path < children[j].path
+	})
+
+	top := float64(heatmapPadding)
+	if h > 3*heatmapHeader {
+		top = heatmapHeader
+	}
+	squarify(children, x+heatmapPadding, y+top, w-2*heatmapPadding, h-top-heatmapPadding, tiles)
+}
+
+// squarify lays out nodes, sorted by decreasing size, in rows along the
+// shorter side of the rectangle, adding a node to the current row as long
+// as it makes the tiles of the row closer to squares.
+func squarify(nodes []*treeNode, x, y, w, h float64, tiles *[]Tile) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	total := 0
+	for _, n := range nodes {
+		total += n.mutants
+	}
+	scale := w * h / float64(total)
+
+	for len(nodes) > 0 {
+		short := min(w, h)
+		row := 1
+		for row < len(nodes) && worstRatio(nodes[:row+1], short, scale) <= worstRatio(nodes[:row], short, scale) {
+			row++
+		}
+
+		area := 0.0
+		for _, n := range nodes[:row] {
+			area += float64(n.mutants) * scale
+		}
+
+		if w >= h {
+			thickness := area / h
+			top := y
+			for _, n := range nodes[:row] {
+				size := float64(n.mutants) * scale / thickness
+				place(n, x, top, thickness, size, tiles)
+				top += size
+			}
+			x, w = x+thickness, w-thickness
+		} else {
+			thickness := area / w
+			left := x
+			for _, n := range nodes[:row] {
+				size := float64(n.mutants) * scale / thickness
+				place(n, left, y, size, thickness, tiles)
+				left += size
+			}
+			y, h = y+thickness, h-thickness
+		}
+		nodes = nodes[row:]
+	}
+}
+
+// worstRatio returns the largest aspect ratio of the tiles of a row laid
+// along a side of the given length.
+func worstRatio(row []*treeNode, side, scale float64) float64 {
+	area := 0.0
+	for _, n := range row {
+		area += float64(n.mutants) * scale
+	}
+
+	worst := 0.0
+	for _, n := range row {
+		a := float64(n.mutants) * scale
+		worst = max(worst, side*side*a/(area*area), area*area/(side*side*a))
+	}
+	return worst
+}
+
+var heatmapPage = template.Must(template.New("heatmap").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>selene heatmap</title>
+<style>
+body { font-family: sans-serif; }
+svg text { font-size: 11px; pointer-events: none; }
+</style>
+</head>
+<body>
+<h1>selene heatmap</h1>
+<p>Tiles are sized by mutants and colored by the share of them that survived, from green, all killed, to red, all survived. Grey tiles have no tested mutants.</p>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}">
+{{range .Tiles}}{{if .Dir}}<rect x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" width="{{printf "%.1f" .W}}" height="{{printf "%.1f" .H}}" fill="white" stroke="#444"><title>{{.Title}}</title></rect>
+<text x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" dx="3" dy="11">{{.Label}}</text>
+{{else}}<rect x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" width="{{printf "%.1f" .W}}" height="{{printf "%.1f" .H}}" fill="{{.Color}}" stroke="white"><title>{{.Title}}</title></rect>
+<text x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" dx="3" dy="12">{{.Label}}</text>
+{{end}}{{end}}</svg>
+</body>
+</html>
+`))
+
+// RenderHeatmap renders the heatmap of a document as an HTML page with an
+// SVG treemap of the given size.
+func RenderHeatmap(w io.Writer, doc Document, width, height float64) error {
+	return heatmapPage.Execute(w, struct {
+		Width, Height float64
+		Tiles         []Tile
+	}{width, height, Heatmap(doc, width, height)})
+}
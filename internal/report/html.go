@@ -0,0 +1,246 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{"fileURL": fileURL}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{if .InProgress}}<meta http-equiv="refresh" content="5">{{end}}
+<title>selene report</title>
+<style>
+body { font-family: sans-serif; }
+td, th { padding: 2px 8px; text-align: left; vertical-align: top; }
+.caught { color: green; }
+.missed { color: red; }
+</style>
+</head>
+<body>
+<h1>selene report</h1>
+{{if .Served}}
+<form method="get">
+file <input name="file" value="{{.Filter.File}}">
+mutator <input name="mutator" value="{{.Filter.Mutator}}">
+status <select name="status">
+<option value="">any</option>
+{{range .Statuses}}<option value="{{.}}"{{if eq (print .) $.Filter.Status}} selected{{end}}>{{.}}</option>
+{{end}}</select>
+<button>filter</button>
+</form>
+{{end}}{{if .InProgress}}
+<p>Run in progress, this page reloads every few seconds.</p>
+{{else}}{{with .Metadata}}
+<table>
+<tr><th>selene</th><td>{{.Version}}</td></tr>
+<tr><th>mode</th><td>{{.Mode}}</td></tr>
+<tr><th>mutators</th><td>{{range $i, $m := .Mutators}}{{if $i}}, {{end}}{{$m}}{{end}}</td></tr>
+<tr><th>go</th><td>{{.GoVersion}}</td></tr>
+<tr><th>git</th><td>{{.Git.Commit}} {{.Git.Branch}}{{if .Git.Dirty}} (dirty){{end}}</td></tr>
+<tr><th>host</th><td>{{.Host.Name}} {{.Host.OS}}/{{.Host.Arch}}, {{.Host.CPUs}} CPUs</td></tr>
+<tr><th>started</th><td>{{.Start.Format "2006-01-02 15:04:05"}}, took {{.Duration}}</td></tr>
+</table>
+{{end}}{{end}}
+{{with .LongLived}}
+<h2>Long-lived survivors</h2>
+<table>
+<tr><th>Mutant</th><th>Surviving</th></tr>
+{{range .}}<tr><td>{{.ID}}</td><td>{{.Age}}</td></tr>
+{{end}}</table>
+{{end}}
+{{range .Results}}
+<h2>{{.Dir}}</h2>
+<p>go version {{.GoVersion}}</p>
+<table>
+<tr><th>Line</th><th>Mutant</th><th>Elapsed</th><th>Result</th><th>Details</th></tr>
+{{range $line := .Lines}}{{range $i, $m := .Mutants}}{{with $m}}
+<tr>
+{{if not $i}}<td rowspan="{{len $line.Mutants}}">{{$line.Position}}{{if gt (len $line.Mutants) 1}}<br>{{$line.Summary}}{{end}}</td>{{end}}
+<td>{{.ID}}{{if $.Served}}
+<form method="post" action="open" style="display: inline"><input type="hidden" name="id" value="{{.ID}}"><button>open</button></form>{{end}}</td>
+<td>{{printf "%0.2fs" .Elapsed}}</td>
+{{if eq .Status "killed"}}<td class="caught">KILLED</td>{{else if eq .Status "survived"}}<td class="missed">SURVIVED</td>{{else}}<td>BUILD FAILED</td>{{end}}
+<td>{{if .KilledBy}}by {{range $i, $t := .KilledBy}}{{if $i}}, {{end}}{{$t}}{{end}}{{with .Notes}} ({{.}}){{end}}{{else if eq .Status "survived"}}{{with .Change}}<code>{{.}}</code><br>{{end}}{{with .Age}}surviving {{.}}<br>{{end}}<code>{{.Repro}}</code><br><code>{{.GoTest}}</code>{{end}}
+log: <a href="{{fileURL .Log}}">{{.Log}}</a></td>
+</tr>
+{{end}}{{end}}{{end}}
+</table>
+{{with .Clusters}}
+<p>Survivors likely to share a root cause, a test killing one of them would likely kill the others:</p>
+<ul>
+{{range .}}<li>{{len .Mutants}} survivors {{.Reason}}: {{range $i, $m := .Mutants}}{{if $i}}, {{end}}{{$m.ID}}{{end}}</li>
+{{end}}
+</ul>
+{{end}}
+{{if .Panics}}
+<p>{{.Panics}} of {{.Count "killed"}} killed mutants were only killed by panics, the tests may miss subtler changes.</p>
+{{end}}
+{{if .TestedBy}}
+<p>No tests of its own, mutants are tested by the tests of:</p>
+<ul>
+{{range .TestedBy}}<li>{{.}}</li>
+{{end}}
+</ul>
+{{end}}
+{{if .HigherOrder}}
+<p>Higher-order mutants, pairs of mutants tested together. They are experimental and not scored:</p>
+<ul>
+{{range .HigherOrder}}<li>{{.ID}}: {{.Status}}</li>
+{{end}}
+</ul>
+{{end}}
+{{if .AssertionFree}}
+<p>Tests without assertions, they can't kill any mutant:</p>
+<ul>
+{{range .AssertionFree}}<li>{{.}}</li>
+{{end}}
+</ul>
+{{end}}
+{{if .Focused}}
+<p>Ginkgo suites with focused specs, their other specs can't kill any mutant:</p>
+<ul>
+{{range .Focused}}<li>{{.}}</li>
+{{end}}
+</ul>
+{{end}}
+{{if .Skipped}}
+<p>Skipped files:</p>
+<ul>
+{{range .Skipped}}<li>{{.File}}: {{.Reason}}</li>
+{{end}}
+</ul>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// fileURL returns the file URL of an absolute path, which on Windows
+// starts with a drive letter and uses backslashes. html/template would
+// filter the file scheme otherwise. Relative paths, as in bundles, are
+// kept relative.
+func fileURL(path string) template.URL {
+	if !filepath.IsAbs(path) && filepath.VolumeName(path) == "" {
+		return template.URL((&url.URL{Path: filepath.ToSlash(path)}).String())
+	}
+
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+	return template.URL(u.String())
+}
+
+type htmlDir struct {
+	dir     string
+	results []Result
+}
+
+// NewHTML returns a sink writing an index.html page to dir. The page is
+// rewritten as results come in and reloads itself until the run is over.
+func NewHTML(dir string) Sink {
+	return &htmlDir{dir: dir}
+}
+
+func (h *htmlDir) Progress(r Result) error {
+	return h.render(Document{Results: append(slices.Clip(h.results), r), InProgress: true})
+}
+
+func (h *htmlDir) Write(r Result) error {
+	h.results = append(h.results, r)
+	return h.render(Document{Results: h.results, InProgress: true})
+}
+
+func (h *htmlDir) Close(m Metadata) error {
+	return h.render(Document{Metadata: m, Results: h.results})
+}
+
+// view is what the page renders: a document and, when served by selene
+// report serve, the filter applied and the buttons opening files.
+type view struct {
+	Document
+	Served bool
+	Filter Filter
+}
+
+// Statuses lists the statuses the page can filter on.
+func (view) Statuses() []Status {
+	return []Status{Killed, Survived, BuildFailed}
+}
+
+// RenderHTML renders a document as the HTML report.
+func RenderHTML(w io.Writer, doc Document) error {
+	return page.Execute(w, view{Document: doc})
+}
+
+// RenderServedHTML renders the mutants of a document matching filter as
+// the HTML report, with a form to change the filter and buttons posting
+// the ID of a mutant to open, to open its file in an editor.
+func RenderServedHTML(w io.Writer, doc Document, filter Filter) error {
+	return page.Execute(w, view{Document: filter.Apply(doc), Served: true, Filter: filter})
+}
+
+// Filter selects mutants by file, mutator and status. Empty fields match
+// any mutant.
+type Filter struct {
+	File    string // part of the file path or ID, ignoring case
+	Mutator string // name, ignoring case
+	Status  string
+}
+
+// Match reports whether m is selected by the filter.
+func (f Filter) Match(m Mutant) bool {
+	if f.File != "" {
+		file := strings.ToLower(f.File)
+		if !strings.Contains(strings.ToLower(m.File), file) && !strings.Contains(strings.ToLower(m.ID), file) {
+			return false
+		}
+	}
+	if f.Mutator != "" && !strings.EqualFold(f.Mutator, m.Mutator) {
+		return false
+	}
+	return f.Status == "" || f.Status == string(m.Status)
+}
+
+// Apply returns the document with only the mutants matching the filter,
+// leaving out results without any.
+func (f Filter) Apply(doc Document) Document {
+	if f == (Filter{}) {
+		return doc
+	}
+
+	filtered := doc
+	filtered.Results = nil
+	for _, r := range doc.Results {
+		var mutants []Mutant
+		for _, m := range r.Mutants {
+			if f.Match(m) {
+				mutants = append(mutants, m)
+			}
+		}
+		if len(mutants) > 0 {
+			r.Mutants = mutants
+			filtered.Results = append(filtered.Results, r)
+		}
+	}
+	return filtered
+}
+
+func (h *htmlDir) render(doc Document) error {
+	err := os.MkdirAll(h.dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(h.dir, "index.html"), func(w io.Writer) error {
+		return RenderHTML(w, doc)
+	})
+}
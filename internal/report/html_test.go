@@ -0,0 +1,26 @@
+package report
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestFileURL(t *testing.T) {
+	tests := map[string]string{
+		"pkg/a.go":         "pkg/a.go",
+		"dir with space/a": "dir%20with%20space/a",
+	}
+	if runtime.GOOS == "windows" {
+		tests[`C:\src\a.go`] = "file:///C:/src/a.go"
+		tests[`pkg\a.go`] = "pkg/a.go"
+	} else {
+		tests["/src/a.go"] = "file:///src/a.go"
+		tests["/src/my dir/a.go"] = "file:///src/my%20dir/a.go"
+	}
+
+	for path, want := range tests {
+		if got := string(fileURL(path)); got != want {
+			t.Errorf("fileURL(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
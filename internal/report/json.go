@@ -0,0 +1,40 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+)
+
+type jsonFile struct {
+	filename string
+	results  []Result
+}
+
+// NewJSON returns a sink writing all results to filename. The file is
+// rewritten as results come in, so it is valid even if the run is
+// interrupted, and completed with the metadata when closed.
+func NewJSON(filename string) Sink {
+	return &jsonFile{filename: filename}
+}
+
+func (j *jsonFile) Progress(r Result) error {
+	return j.render(Document{Results: append(slices.Clip(j.results), r), InProgress: true})
+}
+
+func (j *jsonFile) Write(r Result) error {
+	j.results = append(j.results, r)
+	return j.render(Document{Results: j.results, InProgress: true})
+}
+
+func (j *jsonFile) Close(m Metadata) error {
+	return j.render(Document{Metadata: m, Results: j.results})
+}
+
+func (j *jsonFile) render(doc Document) error {
+	return writeFile(j.filename, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc.current())
+	})
+}
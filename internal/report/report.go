@@ -0,0 +1,515 @@
+// Package report renders the results of a mutation run to one or more
+// sinks: the console, files or remote services.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a mutant.
+type Status string
+
+const (
+	Killed      Status = "killed"       // some test failed
+	Survived    Status = "survived"     // all tests passed
+	BuildFailed Status = "build failed" // the mutated code doesn't compile
+)
+
+// Mutant is the outcome of the tests run against a single mutation.
+type Mutant struct {
+	ID       string   `json:"id"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Mutator  string   `json:"mutator"`
+	Version  string   `json:"mutatorVersion"`
+	Status   Status   `json:"status"`
+	KilledBy []string `json:"killedBy,omitempty"` // the failed tests
+	Elapsed  float64  `json:"elapsed"`            // seconds
+	Log      string   `json:"log"`                // compressed go test output
+	Overlay  string   `json:"overlay"`
+	Repro    string   `json:"repro"`  // selene command running only this mutant
+	GoTest   string   `json:"goTest"` // go test command reproducing it by hand
+
+	// Func is the function or method the mutant is in, as Func or
+	// Type.Method, and Before and After are the lines it changed, before
+	// and after the mutation, trimmed and joined by spaces.
+	Func   string `json:"func,omitempty"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+
+	// Tier is ExpensiveTier if the mutant was only killed by the
+	// expensive tests, run in deep mode, and empty otherwise.
+	Tier string `json:"tier,omitempty"`
+
+	// Cause is how the tests killed the mutant: TimeoutCause if they
+	// timed out, PanicCause if they only failed by panicking or crashing,
+	// AssertionCause otherwise.
+	Cause string `json:"cause,omitempty"`
+
+	// SurvivingSince is when a survivor was first seen surviving, if it
+	// was in an earlier run, as recorded in the history.
+	SurvivingSince       *time.Time `json:"survivingSince,omitempty"`
+	SurvivingSinceCommit string     `json:"survivingSinceCommit,omitempty"`
+}
+
+// ExpensiveTier is the tier of the tests too slow to run for every
+// mutant, such as integration tests.
+const ExpensiveTier = "expensive"
+
+// Causes of killed mutants. Tests only killing mutants by panicking, as
+// on a nil dereference, would likely miss subtler changes.
+const (
+	TimeoutCause   = "timeout"
+	PanicCause     = "panic"
+	AssertionCause = "assertion"
+)
+
+// Notes describes how a killed mutant was killed, as in "expensive
+// tests, panicked", or is empty for the regular tests failing an
+// assertion.
+func (m Mutant) Notes() string {
+	var notes []string
+	if m.Tier != "" {
+		notes = append(notes, m.Tier+" tests")
+	}
+	switch m.Cause {
+	case TimeoutCause:
+		notes = append(notes, "timed out")
+	case PanicCause:
+		notes = append(notes, "panicked")
+	}
+	return strings.Join(notes, ", ")
+}
+
+// Change shows the lines the mutant changed, as in "'x < limit' -> 'x
+// <= limit'", or is empty if they aren't known.
+func (m Mutant) Change() string {
+	if m.Before == "" && m.After == "" {
+		return ""
+	}
+	return fmt.Sprintf("'%s' -> '%s'", m.Before, m.After)
+}
+
+// Age describes how long a survivor has been surviving, as in "for 12
+// days since 3f1c2ab9e0d4", or is empty for new survivors.
+func (m Mutant) Age() string {
+	if m.SurvivingSince == nil {
+		return ""
+	}
+
+	age := fmt.Sprintf("for %d days", int(time.Since(*m.SurvivingSince).Hours()/24))
+	if m.SurvivingSinceCommit != "" {
+		commit := m.SurvivingSinceCommit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		age += " since " + commit
+	}
+	return age
+}
+
+// Skipped is a source file that wasn't mutated.
+type Skipped struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// Result is the outcome of the mutation run of a package.
+type Result struct {
+	Dir       string    `json:"dir"`
+	GoVersion string    `json:"goVersion"`
+	Mutants   []Mutant  `json:"mutants"`
+	Skipped   []Skipped `json:"skipped"`
+
+	// AssertionFree are the tests that can't fail, so they can't kill
+	// any mutant either.
+	AssertionFree []string `json:"assertionFree,omitempty"`
+
+	// Focused are the ginkgo suites with focused specs, whose other
+	// specs don't run, so they can't kill any mutant either.
+	Focused []string `json:"focused,omitempty"`
+
+	// TestedBy are the packages whose tests import a package without
+	// tests of its own, as a test helper, and decide its mutants.
+	TestedBy []string `json:"testedBy,omitempty"`
+
+	// CoverProfile is the coverage profile of the baseline, measured
+	// for the coverage report.
+	CoverProfile string `json:"coverProfile,omitempty"`
+
+	// HigherOrder are the pairs of mutants tested together with
+	// --order 2. They are experimental and scored apart from Mutants.
+	HigherOrder []Mutant `json:"higherOrder,omitempty"`
+}
+
+// Count returns how many mutants ended with the given status.
+func (r Result) Count(status Status) int {
+	n := 0
+	for _, m := range r.Mutants {
+		if m.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Panics returns how many mutants were only killed by panics.
+func (r Result) Panics() int {
+	n := 0
+	for _, m := range r.Mutants {
+		if m.Status == Killed && m.Cause == PanicCause {
+			n++
+		}
+	}
+	return n
+}
+
+// Cluster is a group of survivors likely to share a root cause, so that a
+// test killing one of them would likely kill the others too.
+type Cluster struct {
+	Reason  string // what the survivors have in common
+	Mutants []Mutant
+}
+
+// Clusters groups the survivors of the result in the same function, and
+// those making the same change in different places, largest first.
+// Survivors without any other like them are left out.
+func (r Result) Clusters() []Cluster {
+	var clusters []Cluster
+	index := map[string]int{}
+	add := func(key, reason string, m Mutant) {
+		i, ok := index[key]
+		if !ok {
+			i = len(clusters)
+			index[key] = i
+			clusters = append(clusters, Cluster{Reason: reason})
+		}
+		clusters[i].Mutants = append(clusters[i].Mutants, m)
+	}
+
+	for _, m := range r.Mutants {
+		if m.Status != Survived {
+			continue
+		}
+		if m.Func != "" {
+			file := Line{File: m.File, Mutants: []Mutant{m}}.Position()
+			file = file[:strings.LastIndex(file, ":")]
+			add("func\x00"+m.File+"\x00"+m.Func, fmt.Sprintf("in %s of %s", m.Func, file), m)
+		}
+		if m.Before != "" || m.After != "" {
+			add("change\x00"+m.Mutator+"\x00"+m.Before+"\x00"+m.After, fmt.Sprintf("changing %q to %q", m.Before, m.After), m)
+		}
+	}
+
+	var kept []Cluster
+	for _, c := range clusters {
+		if len(c.Mutants) < 2 {
+			continue
+		}
+		sort.SliceStable(c.Mutants, func(i, j int) bool {
+			a, b := c.Mutants[i], c.Mutants[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+		kept = append(kept, c)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return len(kept[i].Mutants) > len(kept[j].Mutants)
+	})
+	return kept
+}
+
+// LongLived returns the survivors seen surviving in earlier runs, the
+// oldest first.
+func (d Document) LongLived() []Mutant {
+	var survivors []Mutant
+	for _, r := range d.Results {
+		for _, m := range r.Mutants {
+			if m.Status == Survived && m.SurvivingSince != nil {
+				survivors = append(survivors, m)
+			}
+		}
+	}
+
+	sort.SliceStable(survivors, func(i, j int) bool {
+		return survivors[i].SurvivingSince.Before(*survivors[j].SurvivingSince)
+	})
+	return survivors
+}
+
+// Line is a source line and the mutants made on it.
+type Line struct {
+	File    string
+	Line    int
+	Mutants []Mutant
+}
+
+// Lines groups the mutants by source line, sorted by file and line.
+// Mutants of a line keep their order.
+func (r Result) Lines() []Line {
+	var lines []Line
+	index := map[string]int{}
+	for _, m := range r.Mutants {
+		key := fmt.Sprintf("%s:%d", m.File, m.Line)
+		i, ok := index[key]
+		if !ok {
+			i = len(lines)
+			index[key] = i
+			lines = append(lines, Line{File: m.File, Line: m.Line})
+		}
+		lines[i].Mutants = append(lines[i].Mutants, m)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].File != lines[j].File {
+			return lines[i].File < lines[j].File
+		}
+		return lines[i].Line < lines[j].Line
+	})
+	return lines
+}
+
+// Position returns the line as it appears in mutant IDs, as in
+// "main.go:12", which is relative to where selene ran when possible.
+func (l Line) Position() string {
+	id := l.Mutants[0].ID
+	// IDs end with :column:mutator, and the file may contain colons
+	end := strings.LastIndex(id, ":")
+	if end > 0 {
+		end = strings.LastIndex(id[:end], ":")
+	}
+	if end <= 0 {
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+	return id[:end]
+}
+
+// Summary counts the mutants of the line by status, as in
+// "2 killed, 1 survived".
+func (l Line) Summary() string {
+	return summary(l.Mutants)
+}
+
+// summary counts mutants by status, as in "2 killed, 1 survived".
+func summary(mutants []Mutant) string {
+	var counts []string
+	for _, status := range []Status{Killed, Survived, BuildFailed} {
+		n := 0
+		for _, m := range mutants {
+			if m.Status == status {
+				n++
+			}
+		}
+		if n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	return strings.Join(counts, ", ")
+}
+
+// Metadata describes how a run was made, so runs can be compared over
+// time and discrepancies between them explained.
+type Metadata struct {
+	Version   string        `json:"version"` // selene version
+	Mode      string        `json:"mode"`
+	Mutators  []string      `json:"mutators"` // as name@version
+	GoVersion string        `json:"goVersion"`
+	Git       Git           `json:"git"`
+	Host      Host          `json:"host"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration"` // nanoseconds
+	Filters   Filters       `json:"filters"`
+	// Seed is the seed of the random choices of the run, the mutants
+	// sampled and the higher-order pairs, which --seed repeats.
+	Seed int64 `json:"seed"`
+}
+
+// Filters are the settings deciding which mutants a run tested, and how
+// their tests ran, so it can be reproduced from its report.
+type Filters struct {
+	Diff             string   `json:"diff,omitempty"` // git ref of --diff
+	ImpactDepth      int      `json:"impactDepth,omitempty"`
+	Since            string   `json:"since,omitempty"`
+	Only             []string `json:"only,omitempty"` // mutant IDs
+	ExportedOnly     bool     `json:"exportedOnly,omitempty"`
+	ExportedHelpers  bool     `json:"exportedHelpers,omitempty"`
+	Packs            []string `json:"packs,omitempty"`
+	Sample           float64  `json:"sample,omitempty"` // percent of the mutants tested
+	Order            int      `json:"order,omitempty"`
+	ExcludeFunctions []string `json:"excludeFunctions,omitempty"` // patterns of the config
+	Env              Env      `json:"env"`
+}
+
+// Env is the host environment passed on to the go commands, as set in
+// the config: all of it when empty.
+type Env struct {
+	Allow   []string `json:"allow,omitempty"`
+	Deny    []string `json:"deny,omitempty"`
+	Isolate bool     `json:"isolate,omitempty"`
+}
+
+// Git is the state of the repository the run was made on. It is empty
+// outside of a git repository.
+type Git struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// Host is the machine the run was made on.
+type Host struct {
+	Name string `json:"name"`
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	CPUs int    `json:"cpus"`
+}
+
+// Document is the JSON representation of a whole run, used by the file
+// and webhook sinks, in the schema of SchemaVersion. While the run is in
+// progress the metadata is empty and the last result may be missing some
+// mutants.
+type Document struct {
+	SchemaVersion int      `json:"schemaVersion,omitempty"` // 0 for version 1
+	Metadata      Metadata `json:"metadata"`
+	Results       []Result `json:"results"`
+	InProgress    bool     `json:"inProgress,omitempty"`
+}
+
+// Sink is a destination for results. Write is called once per package,
+// as soon as it is tested, and Close once the run is over with the
+// metadata of the run.
+type Sink interface {
+	Write(r Result) error
+	Close(m Metadata) error
+}
+
+// Progress is implemented by sinks that render results as they come in.
+// It is called with the partial result of a package after each mutant,
+// before Write is called with the complete one.
+type Progress interface {
+	Progress(r Result) error
+}
+
+// Open creates a sink from its --report value: console, github,
+// json=<file>, html=<dir> or webhook=<url>. The console is written to
+// stdout in the given format, as are the GitHub annotations.
+func Open(spec string, stdout io.Writer, consoleFormat string) (Sink, error) {
+	kind, target, _ := strings.Cut(spec, "=")
+
+	switch kind {
+	case "console":
+		return NewConsole(stdout, consoleFormat), nil
+	case "github":
+		return NewGitHub(stdout, os.Getenv("GITHUB_WORKSPACE")), nil
+	case "json", "html", "webhook", "coverage":
+		if target == "" {
+			return nil, fmt.Errorf("report %s needs a target, as in %s=<target>", kind, kind)
+		}
+	default:
+		return nil, fmt.Errorf("unknown report %q, expected console, github, json, html, coverage or webhook", kind)
+	}
+
+	switch kind {
+	case "json":
+		return NewJSON(target), nil
+	case "html":
+		return NewHTML(target), nil
+	case "coverage":
+		return NewCoverage(target), nil
+	default:
+		return NewWebhook(target), nil
+	}
+}
+
+type multi []Sink
+
+// Multi fans results out to all sinks. They are called in turn, as
+// several may write to the same output, console ones first: the line the
+// dots format leaves unterminated is ended before the others write, as
+// GitHub annotations are only parsed at the start of a line. Every sink
+// is written and closed even if another one fails.
+func Multi(sinks ...Sink) Sink {
+	m := slices.Clone(sinks)
+	slices.SortStableFunc(m, func(a, b Sink) int {
+		_, aConsole := a.(*console)
+		_, bConsole := b.(*console)
+		switch {
+		case aConsole && !bConsole:
+			return -1
+		case bConsole && !aConsole:
+			return 1
+		}
+		return 0
+	})
+	return multi(m)
+}
+
+// each calls fn for every sink and joins their errors.
+func (m multi) each(fn func(s Sink) error) error {
+	var errs []error
+	for _, s := range m {
+		errs = append(errs, fn(s))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multi) Progress(r Result) error {
+	return m.each(func(s Sink) error {
+		p, ok := s.(Progress)
+		if !ok {
+			return nil
+		}
+		return p.Progress(r)
+	})
+}
+
+func (m multi) Write(r Result) error {
+	return m.each(func(s Sink) error { return s.Write(r) })
+}
+
+func (m multi) Close(md Metadata) error {
+	return m.each(func(s Sink) error { return s.Close(md) })
+}
+
+// writeFile replaces filename with what render writes, through a
+// temporary file so readers never see it half written.
+func writeFile(filename string, render func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = f.Chmod(0o644)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = render(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
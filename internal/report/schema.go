@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON reports this build writes.
+// Version 1 reports, written before it was recorded, have no
+// schemaVersion and list packages and mutants in the order they were
+// tested. Version 2 sorts every collection, so the reports of a run are
+// the same however its packages and mutants were scheduled.
+const SchemaVersion = 2
+
+// Convert returns doc as a report of the given schema version, for
+// consumers that only know older ones. Reports can be converted to any
+// version this build knows.
+func Convert(doc Document, version int) (Document, error) {
+	switch {
+	case version < 1 || version > SchemaVersion:
+		return doc, fmt.Errorf("unknown schema version %d, expected 1 to %d", version, SchemaVersion)
+	case version == 1:
+		doc.SchemaVersion = 0
+		return doc, nil
+	}
+
+	doc = doc.sorted()
+	doc.SchemaVersion = version
+	return doc, nil
+}
+
+// current returns doc as a report of SchemaVersion.
+func (d Document) current() Document {
+	doc, _ := Convert(d, SchemaVersion)
+	return doc
+}
+
+// sorted returns a copy of d with its collections sorted: packages by
+// directory, mutants by file, line, column, mutator and variant, and
+// files and test names alphabetically. d is left alone, as the results
+// are shared between sinks.
+func (d Document) sorted() Document {
+	d.Metadata.Mutators = sortedStrings(d.Metadata.Mutators)
+
+	results := make([]Result, len(d.Results))
+	for i, r := range d.Results {
+		r.Mutants = slices.Clone(r.Mutants)
+		for j := range r.Mutants {
+			r.Mutants[j].KilledBy = sortedStrings(r.Mutants[j].KilledBy)
+		}
+		sort.SliceStable(r.Mutants, func(i, j int) bool {
+			return mutantLess(r.Mutants[i], r.Mutants[j])
+		})
+
+		r.HigherOrder = slices.Clone(r.HigherOrder)
+		for j := range r.HigherOrder {
+			r.HigherOrder[j].KilledBy = sortedStrings(r.HigherOrder[j].KilledBy)
+		}
+		sort.SliceStable(r.HigherOrder, func(i, j int) bool {
+			return mutantLess(r.HigherOrder[i], r.HigherOrder[j])
+		})
+
+		r.Skipped = slices.Clone(r.Skipped)
+		sort.SliceStable(r.Skipped, func(i, j int) bool {
+			return r.Skipped[i].File < r.Skipped[j].File
+		})
+
+		r.AssertionFree = sortedStrings(r.AssertionFree)
+		r.Focused = sortedStrings(r.Focused)
+		r.TestedBy = sortedStrings(r.TestedBy)
+		results[i] = r
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Dir < results[j].Dir
+	})
+	d.Results = results
+
+	return d
+}
+
+func mutantLess(a, b Mutant) bool {
+	switch {
+	case a.File != b.File:
+		return a.File < b.File
+	case a.Line != b.Line:
+		return a.Line < b.Line
+	case a.Column != b.Column:
+		return a.Column < b.Column
+	case a.Mutator != b.Mutator:
+		return a.Mutator < b.Mutator
+	}
+	return variant(a.ID) < variant(b.ID)
+}
+
+// variant returns the number of a mutant among those its mutator made at
+// the same position, as in the 2 of file.go:12:5:ReturnValue/2, or -1 for
+// the only one.
+func variant(id string) int {
+	_, name, _ := strings.Cut(id[strings.LastIndex(id, ":")+1:], "/")
+	n, err := strconv.Atoi(name)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func sortedStrings(s []string) []string {
+	s = slices.Clone(s)
+	sort.Strings(s)
+	return s
+}
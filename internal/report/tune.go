@@ -0,0 +1,188 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Thresholds of the recommendations of Tune.
+const (
+	// tuneMinSurvivors is how many survivors a mutator needs before its
+	// survivors say anything about it.
+	tuneMinSurvivors = 5
+	// tuneChronicShare is the share of survivors left surviving every
+	// run above which they are taken as accepted by the team.
+	tuneChronicShare = 0.9
+	// tuneBuildFailedShare is the share of mutants that don't build
+	// above which a mutator mostly wastes time.
+	tuneBuildFailedShare = 0.5
+	// tuneKilledShare is the share of killed mutants elsewhere above
+	// which chronic survivors of a package stand out.
+	tuneKilledShare = 0.8
+)
+
+// MutatorStats are the outcomes of the mutants of a mutator in the last
+// of several runs.
+type MutatorStats struct {
+	Mutator     string `json:"mutator"`
+	Mutants     int    `json:"mutants"`
+	Killed      int    `json:"killed"`
+	Survived    int    `json:"survived"`
+	BuildFailed int    `json:"buildFailed"`
+	// Chronic are the survivors that survived in every earlier run
+	// they were in, at least one, which nobody cared to kill.
+	Chronic int `json:"chronic"`
+}
+
+// Recommendation is a change to the mutators run, or to the tests, that
+// the outcomes of several runs suggest.
+type Recommendation struct {
+	Action  string `json:"action"` // disable or test
+	Mutator string `json:"mutator"`
+	Dir     string `json:"dir,omitempty"` // package, empty for all of them
+	Reason  string `json:"reason"`
+}
+
+func (r Recommendation) String() string {
+	action := "disable " + r.Mutator
+	if r.Action == "test" {
+		action = "write tests for " + r.Mutator
+	}
+	if r.Dir != "" {
+		action += " on " + r.Dir
+	}
+	return action + ": " + r.Reason
+}
+
+// Tuning are the outcomes of each mutator over several runs, and the
+// recommendations drawn from them.
+type Tuning struct {
+	Runs            int              `json:"runs"`
+	Mutators        []MutatorStats   `json:"mutators"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// Tune compares the outcomes of the mutators over several runs, sorted
+// by when they started, and recommends disabling those whose survivors
+// are left alone run after run or whose mutants mostly don't build, and
+// writing tests for packages where a mutator otherwise killed survives.
+// Mutants are matched by ID and mutator version, as in Compare.
+func Tune(docs []Document) Tuning {
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Metadata.Start.Before(docs[j].Metadata.Start)
+	})
+	last := docs[len(docs)-1]
+
+	// survivors of the last run that survived every earlier run they
+	// were in
+	var earlier []map[string]Mutant
+	for _, doc := range docs[:len(docs)-1] {
+		earlier = append(earlier, mutants(doc))
+	}
+	chronic := map[string]bool{}
+	for key, m := range mutants(last) {
+		if m.Status != Survived {
+			continue
+		}
+		seen := false
+		survived := true
+		for _, run := range earlier {
+			if prev, ok := run[key]; ok {
+				seen = true
+				survived = survived && prev.Status == Survived
+			}
+		}
+		chronic[key] = seen && survived
+	}
+
+	stats := map[string]*MutatorStats{}
+	byDir := map[string]map[string]*MutatorStats{} // mutator, package
+	for _, r := range last.Results {
+		for _, m := range r.Mutants {
+			for _, s := range []*MutatorStats{statsOf(stats, m.Mutator), statsOf(dirStats(byDir, m.Mutator), r.Dir)} {
+				s.Mutator = m.Mutator
+				s.Mutants++
+				switch m.Status {
+				case Killed:
+					s.Killed++
+				case Survived:
+					s.Survived++
+				default:
+					s.BuildFailed++
+				}
+				if chronic[m.ID+"@"+m.Version] {
+					s.Chronic++
+				}
+			}
+		}
+	}
+
+	t := Tuning{Runs: len(docs)}
+	for _, s := range stats {
+		t.Mutators = append(t.Mutators, *s)
+	}
+	sort.Slice(t.Mutators, func(i, j int) bool {
+		return t.Mutators[i].Mutator < t.Mutators[j].Mutator
+	})
+
+	for _, s := range t.Mutators {
+		switch {
+		case s.Survived >= tuneMinSurvivors && share(s.Chronic, s.Survived) >= tuneChronicShare:
+			t.Recommendations = append(t.Recommendations, Recommendation{
+				Action:  "disable",
+				Mutator: s.Mutator,
+				Reason:  fmt.Sprintf("%.0f%% of its %d survivors survived every run, they are likely accepted", share(s.Chronic, s.Survived)*100, s.Survived),
+			})
+			continue
+		case share(s.BuildFailed, s.Mutants) >= tuneBuildFailedShare:
+			t.Recommendations = append(t.Recommendations, Recommendation{
+				Action:  "disable",
+				Mutator: s.Mutator,
+				Reason:  fmt.Sprintf("%.0f%% of its %d mutants don't build, costing time for nothing", share(s.BuildFailed, s.Mutants)*100, s.Mutants),
+			})
+			continue
+		}
+
+		var dirs []string
+		for dir := range byDir[s.Mutator] {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			d := byDir[s.Mutator][dir]
+			killed, tested := s.Killed-d.Killed, s.Killed+s.Survived-d.Killed-d.Survived
+			if d.Chronic >= tuneMinSurvivors && share(killed, tested) >= tuneKilledShare {
+				t.Recommendations = append(t.Recommendations, Recommendation{
+					Action:  "test",
+					Mutator: s.Mutator,
+					Dir:     dir,
+					Reason:  fmt.Sprintf("%d survivors survived every run, while %.0f%% of its mutants are killed elsewhere", d.Chronic, share(killed, tested)*100),
+				})
+			}
+		}
+	}
+
+	return t
+}
+
+func statsOf(stats map[string]*MutatorStats, key string) *MutatorStats {
+	if stats[key] == nil {
+		stats[key] = &MutatorStats{}
+	}
+	return stats[key]
+}
+
+func dirStats(byDir map[string]map[string]*MutatorStats, mutator string) map[string]*MutatorStats {
+	if byDir[mutator] == nil {
+		byDir[mutator] = map[string]*MutatorStats{}
+	}
+	return byDir[mutator]
+}
+
+// share returns n as a fraction of total, 0 if total is.
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
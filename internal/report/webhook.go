@@ -0,0 +1,45 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type webhook struct {
+	url     string
+	results []Result
+}
+
+// NewWebhook returns a sink posting all results as JSON to url when
+// closed.
+func NewWebhook(url string) Sink {
+	return &webhook{url: url}
+}
+
+func (w *webhook) Write(r Result) error {
+	w.results = append(w.results, r)
+	return nil
+}
+
+func (w *webhook) Close(m Metadata) error {
+	body, err := json.Marshal(Document{Metadata: m, Results: w.results}.current())
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %s", w.url, resp.Status)
+	}
+
+	return nil
+}
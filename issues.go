@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// issueLabel marks the issues managed by selene issues sync.
+const issueLabel = "selene"
+
+// issueMarker is the hidden comment identifying the group of an issue in
+// its body, so later syncs update it instead of filing a new one.
+var issueMarker = regexp.MustCompile(`<!-- selene:issue (\S+) -->`)
+
+// issuesCommand runs the selene issues subcommands. The only one so far
+// is sync.
+func issuesCommand(args []string) error {
+	usage := &ConfigError{Err: fmt.Errorf("usage: selene issues sync [--repo owner/name] [--min-age 30d] [--group file|owner] [--dry-run] <report.json>")}
+	if len(args) == 0 || args[0] != "sync" {
+		return usage
+	}
+
+	fs := flag.NewFlagSet("issues sync", flag.ContinueOnError)
+	repo := fs.String("repo", os.Getenv("GITHUB_REPOSITORY"), "GitHub `repository` to file issues in, as owner/name")
+	minAge := fs.String("min-age", "30d", "how long a mutant must have survived to be filed, as in 72h, 30d or 4w")
+	group := fs.String("group", "file", "one issue per source file, or per owner in CODEOWNERS")
+	dryRun := fs.Bool("dry-run", false, "print what would be done without calling GitHub")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+	if fs.NArg() != 1 {
+		return usage
+	}
+
+	age, err := parseWindow(*minAge)
+	if err != nil {
+		return &ConfigError{Err: fmt.Errorf("invalid --min-age %q: %s", *minAge, err)}
+	}
+	if *group != "file" && *group != "owner" {
+		return &ConfigError{Err: fmt.Errorf("unknown --group %q, expected file or owner", *group)}
+	}
+	if *repo == "" {
+		return &ConfigError{Err: fmt.Errorf("--repo or GITHUB_REPOSITORY is required")}
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" && !*dryRun {
+		return &ConfigError{Err: fmt.Errorf("GITHUB_TOKEN is required")}
+	}
+
+	doc, err := report.ReadDocument(fs.Arg(0))
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	root := git("rev-parse", "--show-toplevel")
+	if root == "" {
+		return &ConfigError{Err: fmt.Errorf("selene issues sync needs a git repository")}
+	}
+
+	var owners []codeowner
+	if *group == "owner" {
+		owners, err = readCodeowners(root)
+		if err != nil {
+			return &ConfigError{Err: err}
+		}
+	}
+
+	groups := survivorGroups(doc, root, *group, owners, time.Now().Add(-age))
+
+	// a dry run without a token can't tell what is filed already
+	gh := &github{api: githubAPI(), repo: *repo, token: token}
+	var existing map[string]githubIssue
+	if token != "" {
+		existing, err = gh.openIssues()
+		if err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	did := func(done, planned string) string {
+		if *dryRun {
+			return "would " + planned
+		}
+		return done
+	}
+	for _, key := range keys {
+		g := groups[key]
+		title, body := g.title(), g.body(key, age)
+		issue, filed := existing[key]
+
+		switch {
+		case len(g.mutants) == 0 && filed:
+			if !*dryRun {
+				err = gh.update(issue.Number, map[string]string{"state": "closed"})
+			}
+			fmt.Printf("%s #%d %s\n", did("closed", "close"), issue.Number, issue.Title)
+		case len(g.mutants) == 0:
+			// nothing to file
+		case !filed:
+			if !*dryRun {
+				err = gh.create(title, body)
+			}
+			fmt.Printf("%s %s, %d mutants\n", did("filed", "file"), title, len(g.mutants))
+		case issue.Body != body:
+			if !*dryRun {
+				err = gh.update(issue.Number, map[string]string{"title": title, "body": body})
+			}
+			fmt.Printf("%s #%d %s, %d mutants\n", did("updated", "update"), issue.Number, title, len(g.mutants))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// issueGroup is the content of an issue: the survivors of a file, or of
+// the files of an owner.
+type issueGroup struct {
+	name    string // file, or owner
+	byOwner bool
+	mutants []report.Mutant
+}
+
+func (g *issueGroup) title() string {
+	if g.byOwner {
+		return "Surviving mutants owned by " + g.name
+	}
+	return "Surviving mutants in " + g.name
+}
+
+func (g *issueGroup) body(key string, minAge time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- selene:issue %s -->\n", key)
+	fmt.Fprintf(&b, "These mutants survived the tests for at least %d days: no test notices these changes to the code. Add tests killing them, or exclude the code if it isn't worth testing.\n\n", int(minAge.Hours()/24))
+	// the date survivors were first seen rather than their age, which
+	// would change the body, and update every issue, on every sync
+	fmt.Fprintf(&b, "| Mutant | Surviving since | Reproduce |\n|--------|-----------------|-----------|\n")
+	for _, m := range g.mutants {
+		since := m.SurvivingSince.UTC().Format(time.DateOnly)
+		if commit := m.SurvivingSinceCommit; commit != "" {
+			since += " (" + commit[:min(len(commit), 12)] + ")"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | `%s` |\n", m.ID, since, m.Repro)
+	}
+	fmt.Fprintf(&b, "\nThis issue is updated by `selene issues sync`, and closed once all these mutants are killed.\n")
+	return b.String()
+}
+
+// survivorGroups groups the mutants of the report by file or owner. Groups
+// without survivors older than cutoff are kept empty, so their issues can
+// be closed: the report covered them and found nothing to file.
+func survivorGroups(doc report.Document, root, by string, owners []codeowner, cutoff time.Time) map[string]*issueGroup {
+	groups := map[string]*issueGroup{}
+	add := func(key, name string, byOwner bool) *issueGroup {
+		if groups[key] == nil {
+			groups[key] = &issueGroup{name: name, byOwner: byOwner}
+		}
+		return groups[key]
+	}
+
+	for _, r := range doc.Results {
+		for _, m := range r.Mutants {
+			rel, err := filepath.Rel(root, m.File)
+			if err != nil {
+				rel = m.File
+			}
+			rel = filepath.ToSlash(rel)
+
+			var gs []*issueGroup
+			if by == "owner" {
+				for _, owner := range ownersOf(owners, rel) {
+					gs = append(gs, add("owner:"+owner, owner, true))
+				}
+			} else {
+				gs = append(gs, add("file:"+rel, rel, false))
+			}
+
+			old := m.Status == report.Survived && m.SurvivingSince != nil && !m.SurvivingSince.After(cutoff)
+			for _, g := range gs {
+				if old {
+					g.mutants = append(g.mutants, m)
+				}
+			}
+		}
+	}
+
+	return groups
+}
+
+// codeowner is a rule of a CODEOWNERS file.
+type codeowner struct {
+	pattern string
+	owners  []string
+}
+
+// readCodeowners reads the CODEOWNERS file of the repository at root, in
+// any of the locations GitHub looks for it.
+func readCodeowners(root string) ([]codeowner, error) {
+	for _, name := range []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"} {
+		f, err := os.Open(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		var rules []codeowner
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			fields := strings.Fields(line)
+			if len(fields) > 0 {
+				rules = append(rules, codeowner{pattern: fields[0], owners: fields[1:]})
+			}
+		}
+		return rules, scanner.Err()
+	}
+
+	return nil, fmt.Errorf("no CODEOWNERS file in %s", root)
+}
+
+// ownersOf returns the owners of file, relative to the repository root,
+// as given by the last matching rule.
+func ownersOf(rules []codeowner, file string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matchCodeowner(rules[i].pattern, file) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// matchCodeowner reports whether a CODEOWNERS pattern matches file. Like
+// gitignore patterns, those without a slash but at the end match at any
+// depth, the others from the root, and matching a directory matches all
+// the files below it.
+func matchCodeowner(pattern, file string) bool {
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "/**"), "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "*" || pattern == "" {
+		return true
+	}
+
+	elems := strings.Split(file, "/")
+	n := strings.Count(pattern, "/") + 1
+	for start := 0; start+n <= len(elems); start++ {
+		if ok, _ := path.Match(pattern, strings.Join(elems[start:start+n], "/")); ok {
+			return true
+		}
+		if anchored {
+			break
+		}
+	}
+	return false
+}
+
+// github is a minimal client of the GitHub issues API.
+type github struct {
+	api   string
+	repo  string
+	token string
+}
+
+type githubIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+// githubAPI returns the GitHub API URL, which GitHub Enterprise runners
+// set in GITHUB_API_URL.
+func githubAPI() string {
+	if api := os.Getenv("GITHUB_API_URL"); api != "" {
+		return strings.TrimSuffix(api, "/")
+	}
+	return "https://api.github.com"
+}
+
+// openIssues returns the open issues filed by selene, by group.
+func (gh *github) openIssues() (map[string]githubIssue, error) {
+	issues := map[string]githubIssue{}
+	for page := 1; ; page++ {
+		var batch []githubIssue
+		err := gh.do(http.MethodGet, fmt.Sprintf("/repos/%s/issues?labels=%s&state=open&per_page=100&page=%d", gh.repo, issueLabel, page), nil, &batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range batch {
+			if m := issueMarker.FindStringSubmatch(issue.Body); m != nil {
+				issues[m[1]] = issue
+			}
+		}
+		if len(batch) < 100 {
+			return issues, nil
+		}
+	}
+}
+
+func (gh *github) create(title, body string) error {
+	return gh.do(http.MethodPost, fmt.Sprintf("/repos/%s/issues", gh.repo), map[string]any{
+		"title":  title,
+		"body":   body,
+		"labels": []string{issueLabel},
+	}, nil)
+}
+
+func (gh *github) update(number int, fields map[string]string) error {
+	return gh.do(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", gh.repo, number), fields, nil)
+}
+
+func (gh *github) do(method, endpoint string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, gh.api+endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+gh.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitHub %s %s returned %s: %s", method, endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
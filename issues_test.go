@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+func TestIssueGroupBody(t *testing.T) {
+	since := time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC)
+	g := &issueGroup{name: "a.go", mutants: []report.Mutant{
+		{ID: "a.go:3:9:Negation", SurvivingSince: &since, SurvivingSinceCommit: "0123456789abcdef", Repro: "selene run --only a.go:3:9:Negation a.go"},
+	}}
+
+	body := g.body("file:a.go", 30*24*time.Hour)
+	row := "| `a.go:3:9:Negation` | 2026-01-03 (0123456789ab) | `selene run --only a.go:3:9:Negation a.go` |"
+	if !strings.Contains(body, row) {
+		t.Errorf("body() has no row %s:\n%s", row, body)
+	}
+
+	// sync updates the issues whose body changed
+	if again := g.body("file:a.go", 30*24*time.Hour); again != body {
+		t.Errorf("body() changed between calls:\n%s\n%s", body, again)
+	}
+}
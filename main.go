@@ -1,42 +1,544 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/printer"
-	"go/token"
 	"io"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"golang.org/x/tools/go/ast/astutil"
+	"github.com/danicat/selene/internal/mutator"
+	"github.com/danicat/selene/internal/report"
 )
 
 const GOMUTATION = "GOMUTATION"
 
+// the go test -timeout of mutants is timeoutFactor times the baseline
+// duration plus minTimeout
+const (
+	timeoutFactor = 3
+	minTimeout    = 10 * time.Second
+)
+
+// preset is a named set of run settings selected with --mode. Teams can
+// define their own in the presets of the config, or change the built-in
+// ones. Mutators, Packs and Since only apply when the matching flags
+// aren't given.
+type preset struct {
+	Short     bool     `json:"short"`     // pass -short to go test
+	Expensive bool     `json:"expensive"` // run the expensive tests on survivors
+	SkipSlow  bool     `json:"skipSlow"`  // leave out slow mutators not named in --mutators
+	Sample    float64  `json:"sample"`    // percent of the mutants tested, all if 0
+	Since     string   `json:"since"`     // as --since
+	Mutators  []string `json:"mutators"`  // as --mutators
+	Packs     []string `json:"packs"`     // as --packs
+}
+
+var presets = map[string]preset{
+	"quick": {Short: true, SkipSlow: true, Sample: 50, Since: "1w"},
+	"full":  {},
+	"deep":  {Expensive: true},
+}
+
+// findPreset returns the preset named mode, among the built-in ones and
+// those of the config, which take precedence.
+func findPreset(mode string, custom map[string]preset) (preset, error) {
+	p, ok := custom[mode]
+	if !ok {
+		p, ok = presets[mode]
+	}
+	if !ok {
+		var names []string
+		for name := range presets {
+			names = append(names, name)
+		}
+		for name := range custom {
+			if _, ok := presets[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return p, &ConfigError{Err: fmt.Errorf("unknown mode %q, expected one of %s", mode, strings.Join(names, ", "))}
+	}
+
+	if p.Sample < 0 || p.Sample > 100 {
+		return p, &ConfigError{Err: fmt.Errorf("invalid sample %g of preset %s, expected a percent between 0 and 100", p.Sample, mode)}
+	}
+	return p, nil
+}
+
+// testFlags returns the extra go test flags for the preset.
+func (p preset) testFlags() []string {
+	var flags []string
+	if p.Short {
+		flags = append(flags, "-short")
+	}
+	return flags
+}
+
+type options struct {
+	mode        string
+	mutators    string
+	packs       string
+	since       string
+	overlay     string
+	reports     []string
+	allowRoots  []string
+	diff        string
+	impactDepth int
+	exported    bool
+	helpers     bool
+	config      string
+	only        string
+	history     string
+	strict      bool
+	toolchain   toolchain
+	workers     int
+	parallel    int
+	batch       int
+	order       int
+	seed        int64
+	format      string
+	verbose     bool
+	events      *report.Events // from --events, nil if not set
+	discover    bool           // selene run without files, skipping files by default
+}
+
 func usage() {
-	fmt.Println("Usage:\nselene file.go")
+	flag.CommandLine.SetOutput(os.Stdout)
+	fmt.Println("Usage:\nselene [run] [flags] file.go\nselene run [flags]\nselene run-all [flags]\nselene exec [flags] -- go test [flags] [packages]\nselene compare --before <report.json> --after <report.json>\nselene compare --before-ref <ref> [--after-ref <ref>] [run|run-all] [flags] [files]\nselene bundle [-o results.tar.gz] <report.json>\nselene bundle open [-addr localhost:8000] <results.tar.gz>\nselene heatmap [-o heatmap.html] [-width 1200] [-height 800] <report.json>\nselene report serve [-addr localhost:8000] <report.json>\nselene report tune [-format text|json] <report.json>...\nselene report convert [-to version] [-o file] <report.json>\nselene issues sync [--repo owner/name] [--min-age 30d] [--group file|owner] [--dry-run] <report.json>\nselene gerrit review [--url url] [--change n] [--label name] [--min-score 80] [--dry-run] <report.json>\nselene docs mutators [--format markdown|json]")
+	flag.PrintDefaults()
 }
 
 func main() {
 	log.SetOutput(io.Discard)
 
-	if len(os.Args) < 2 {
+	args := os.Args[1:]
+
+	var err error
+	switch {
+	case len(args) > 0 && args[0] == "docs":
+		err = docs(args[1:])
+	case len(args) > 0 && args[0] == "compare":
+		err = compare(args[1:])
+	case len(args) > 0 && args[0] == "bundle":
+		err = bundle(args[1:])
+	case len(args) > 0 && args[0] == "heatmap":
+		err = heatmap(args[1:])
+	case len(args) > 0 && args[0] == "report":
+		err = reportCommand(args[1:])
+	case len(args) > 0 && args[0] == "issues":
+		err = issuesCommand(args[1:])
+	case len(args) > 0 && args[0] == "gerrit":
+		err = gerritCommand(args[1:])
+	default:
+		err = mutationTest(args)
+	}
+
+	if err != nil && !errors.As(err, new(*ThresholdError)) {
+		fmt.Fprintf(os.Stderr, "selene: %s\n", err)
+	}
+
+	os.Exit(exitCode(err))
+}
+
+// mutationTest parses the flags and runs the mutation tests for the given
+// files, for the whole tree with run-all, or for the packages tested by a
+// go test command with exec.
+func mutationTest(args []string) error {
+	var opts options
+	flag.StringVar(&opts.mode, "mode", "full", "run preset: quick passes -short to go test, skips slow mutators and tests half of the mutants of the lines changed in the last week, full runs everything but the expensive tests of the config, deep runs those too on survivors; presets of the config can add others")
+	flag.StringVar(&opts.mutators, "mutators", "", "comma separated `names` of the mutators to apply (default all but the opt-in ones)")
+	flag.StringVar(&opts.packs, "packs", "", "comma separated `names` of mutator packs to apply, opt-in members included, in addition to --mutators")
+	flag.StringVar(&opts.config, "config", defaultConfig, "config `file`")
+	flag.StringVar(&opts.diff, "diff", "", "only mutate functions changed since the git `ref`")
+	flag.IntVar(&opts.impactDepth, "impact-depth", 0, "with --diff, also mutate callers and callees of the changed functions up to this many calls away")
+	flag.BoolVar(&opts.exported, "exported-only", false, "only mutate exported functions, and exported methods of exported types, to measure how well the API of a package is tested")
+	flag.BoolVar(&opts.helpers, "exported-helpers", false, "with --exported-only, also mutate the unexported functions they call directly")
+	flag.StringVar(&opts.since, "since", "", "only test and score mutants on lines added or modified since a git `ref` or within a time window, as in 72h or 2w")
+	flag.StringVar(&opts.only, "only", "", "comma separated `ids` of the mutants to run, as printed for survivors")
+	flag.StringVar(&opts.history, "history", "", "`file` recording which tests kill mutants, to run them first with -failfast in later runs")
+	flag.BoolVar(&opts.strict, "strict", false, "fail instead of silently testing less: on files that can't be mutated, packages outside a module or type errors in --diff analysis")
+	flag.StringVar(&opts.toolchain.goBin, "go", "go", "go `binary` used to build and test")
+	flag.StringVar(&opts.toolchain.goos, "goos", "", "target `GOOS` of the tests, run through --exec unless the host can run them")
+	flag.StringVar(&opts.toolchain.goarch, "goarch", "", "target `GOARCH` of the tests")
+	flag.StringVar(&opts.toolchain.exec, "exec", "", "`program` running the test binaries, as with go test -exec")
+	var docker bool
+	flag.BoolVar(&docker, "docker", false, "run the go commands in the pinned "+dockerImage+" image, for hosts without a go toolchain")
+	flag.IntVar(&opts.workers, "workers", 0, "how many mutants to test at once (default as many as the CPUs and memory fit, measured on the baseline)")
+	flag.IntVar(&opts.parallel, "parallel", 0, "-p and -parallel passed to each go test run (default decided from the workers and t.Parallel usage)")
+	flag.Int64Var(&opts.seed, "seed", 0, "`seed` of the mutants sampled by presets and of the higher-order pairs, as recorded in reports, to repeat a run (default random)")
+	flag.IntVar(&opts.order, "order", 1, "experimental: with 2, also test random pairs of mutants of the same file applied together, reported apart from the score")
+	flag.IntVar(&opts.batch, "batch", 0, "test up to `n` mutants of the same file, in different functions, in a single go test run, and each one on its own only if any test fails")
+	flag.StringVar(&opts.format, "format", report.Verbose, "console report `format`: verbose, like go test -v with the commands reproducing survivors, condensed, a line per package and survivor and a final summary, or dots, a character per mutant")
+	flag.BoolVar(&opts.verbose, "v", false, "log what selene is doing to stderr")
+	flag.Func("allow-root", "`dir` outside the module of the working directory whose files may be mutated; can be repeated", func(s string) error {
+		opts.allowRoots = append(opts.allowRoots, s)
+		return nil
+	})
+	flag.StringVar(&opts.overlay, "overlay", "", "go build overlay `file` to merge with the mutated files")
+	var eventsTarget string
+	flag.StringVar(&eventsTarget, "events", "", "`file` receiving newline-delimited JSON events as the run progresses, - for stdout")
+	flag.Func("report", "where to report results: console, github, json=<file>, html=<dir>, coverage=<dir> or webhook=<url>; can be repeated (default console)", func(s string) error {
+		opts.reports = append(opts.reports, s)
+		return nil
+	})
+	flag.Usage = usage
+
+	var command string
+	if len(args) > 0 && (args[0] == "run" || args[0] == "run-all" || args[0] == "exec") {
+		command, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
+
+	if opts.overlay == "" {
+		opts.overlay = goFlagsOverlay()
+	}
+
+	if opts.verbose {
+		log.SetOutput(os.Stderr)
+	}
+
+	if docker {
+		opts.toolchain.docker = dockerImage
+		err := opts.toolchain.pullImage()
+		if err != nil {
+			return err
+		}
+	}
+
+	if eventsTarget != "" {
+		events, err := report.OpenEvents(eventsTarget, os.Stdout)
+		if err != nil {
+			return &ConfigError{Err: fmt.Errorf("failed to open events: %s", err)}
+		}
+		opts.events = events
+	}
+
+	var err error
+	switch {
+	case command == "run-all":
+		err = runAll(opts, ".")
+	case command == "exec":
+		err = runExec(opts, flag.Args())
+	case command == "run" && flag.NArg() == 0:
+		err = discover(opts)
+	default:
+		err = run(opts, flag.Args())
+	}
+
+	summary := &report.RunSummary{ExitCode: exitCode(err)}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	opts.events.Emit(report.Event{Type: report.RunFinished, Run: summary})
+	if closeErr := opts.events.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to write events: %s", closeErr)
+	}
+
+	var thresholdErr *ThresholdError
+	switch {
+	case err == nil:
+		fmt.Println("PASS")
+	case errors.As(err, &thresholdErr):
+		fmt.Printf("FAIL\n%s\n", err)
+	}
+
+	return err
+}
+
+func run(opts options, filenames []string) error {
+	if len(filenames) == 0 {
 		usage()
-		os.Exit(0)
+		return &ConfigError{Err: fmt.Errorf("no source files")}
+	}
+
+	r, err := newRunner(opts)
+	if err != nil {
+		return err
+	}
+	r.filenames = filenames
+
+	mutationDir, err := makeMutationDir()
+	if err != nil {
+		return err
+	}
+
+	err = r.testPackage(filenames, mutationDir)
+	if err != nil {
+		return err
+	}
+
+	return r.finish()
+}
+
+// runner holds the settings shared by all the packages of a run and
+// tallies their results.
+type runner struct {
+	opts         options
+	start        time.Time
+	seed         int64 // of the random choices of the run
+	preset       preset
+	mutators     []mutator.Mutator
+	excludeFuncs []*regexp.Regexp
+	roots        []string // files outside them are never mutated
+	thresholds   thresholds
+	expensive    *expensiveTests // nil unless configured
+	coverage     bool            // measure the coverage of the baseline
+	userOverlay  map[string]string
+	sink         report.Sink
+	only         map[string]bool // mutant IDs to run, all if empty
+	history      *history        // nil unless --history is set
+	commit       string          // HEAD, with history, to date survivors
+	skipBaseline bool            // the baseline already ran, as with exec
+	goTestFlags  []string        // of the go test command, as with exec
+	runAll       bool
+	filenames    []string // as given on the command line, for run
+
+	onlyFound map[string]bool
+	survived  int
+	total     int
+	scores    map[string]*scopeScore   // by threshold scope
+	usage     map[string]testUsage     // of the baseline, by package directory
+	elapsed   map[string]time.Duration // of the baseline, by package directory
+	// of the go test command, for the packages of exec without a baseline
+	commandElapsed time.Duration
+
+	dependents map[string][]string            // test dependents, by package directory
+	importers  map[string]map[string][]string // reverse test dependencies, by module directory
+
+	rescanMu sync.Mutex
+	rescans  map[string][]mutant // by file, once found stale in the scan cache
+}
+
+func newRunner(opts options) (*runner, error) {
+	cfg, err := loadConfig(opts.config)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := findPreset(opts.mode, cfg.Presets)
+	if err != nil {
+		return nil, err
+	}
+	if opts.mutators == "" && opts.packs == "" {
+		opts.mutators, opts.packs = strings.Join(p.Mutators, ","), strings.Join(p.Packs, ",")
+	}
+	if opts.since == "" {
+		opts.since = p.Since
+	}
+
+	if !slices.Contains(report.ConsoleFormats, opts.format) {
+		return nil, &ConfigError{Err: fmt.Errorf("unknown format %q, expected one of %s", opts.format, strings.Join(report.ConsoleFormats, ", "))}
+	}
+
+	if opts.helpers && !opts.exported {
+		return nil, &ConfigError{Err: fmt.Errorf("--exported-helpers needs --exported-only")}
+	}
+
+	if opts.order != 1 && opts.order != 2 {
+		return nil, &ConfigError{Err: fmt.Errorf("unknown order %d, expected 1 or 2", opts.order)}
+	}
+
+	if opts.batch > 1 && p.Expensive {
+		return nil, &ConfigError{Err: fmt.Errorf("--batch can't be used with --mode deep")}
+	}
+
+	mutators, err := enabledMutators(opts.mutators, opts.packs)
+	if err != nil {
+		return nil, err
+	}
+	if p.SkipSlow {
+		named := splitList(opts.mutators)
+		mutators = slices.DeleteFunc(mutators, func(m mutator.Mutator) bool {
+			return m.Slow && !slices.ContainsFunc(named, func(name string) bool { return strings.EqualFold(name, m.Name) })
+		})
+	}
+
+	roots, err := allowedRoots(opts.allowRoots)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeFuncs, err := compilePatterns("exclude.functions", cfg.Exclude.Functions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkThresholds(cfg.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	retryNames, err := compilePatterns("mutators.retry.names", cfg.Mutators.Retry.Names)
+	if err != nil {
+		return nil, err
+	}
+	mutators = mutator.WithConfig(mutators, mutator.Config{
+		RetryNames: retryNames,
+		GoRemove:   cfg.Mutators.GoStatement.Remove,
+	})
+
+	expensive, err := newExpensiveTests(cfg.Expensive.Tags, cfg.Expensive.Tests)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.toolchain.adapter, err = cfg.Test.check()
+	if err != nil {
+		return nil, err
+	}
+	if opts.toolchain.adapter != nil && opts.toolchain.docker != "" {
+		return nil, &ConfigError{Err: fmt.Errorf("test.command can't be used with --docker")}
+	}
+
+	opts.toolchain.environment, err = cfg.Env.check(opts.toolchain)
+	if err != nil {
+		return nil, err
+	}
+
+	var userOverlay map[string]string
+	if opts.overlay != "" {
+		userOverlay, err = readOverlay(opts.overlay)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	specs := opts.reports
+	if len(specs) == 0 {
+		specs = []string{"console"}
+	}
+
+	var sinks []report.Sink
+	for _, spec := range specs {
+		sink, err := report.Open(spec, os.Stdout, opts.format)
+		if err != nil {
+			return nil, &ConfigError{Err: err}
+		}
+		sinks = append(sinks, sink)
+	}
+
+	var h *history
+	var commit string
+	if opts.history != "" {
+		h, err = loadHistory(opts.history)
+		if err != nil {
+			return nil, err
+		}
+		commit = git("rev-parse", "HEAD")
+	}
+
+	only := map[string]bool{}
+	for _, id := range splitList(opts.only) {
+		only[id] = true
+	}
+
+	start := time.Now()
+	seed := opts.seed
+	if seed == 0 {
+		seed = start.UnixNano()
+	}
+
+	return &runner{
+		opts:         opts,
+		start:        start,
+		seed:         seed,
+		preset:       p,
+		mutators:     mutators,
+		excludeFuncs: excludeFuncs,
+		roots:        roots,
+		thresholds:   cfg.Thresholds,
+		expensive:    expensive,
+		coverage:     slices.ContainsFunc(specs, func(s string) bool { return strings.HasPrefix(s, "coverage=") }),
+		userOverlay:  userOverlay,
+		sink:         report.Multi(sinks...),
+		only:         only,
+		history:      h,
+		commit:       commit,
+		onlyFound:    map[string]bool{},
+		scores:       map[string]*scopeScore{},
+		usage:        map[string]testUsage{},
+		elapsed:      map[string]time.Duration{},
+		dependents:   map[string][]string{},
+		importers:    map[string]map[string][]string{},
+	}, nil
+}
+
+// enabledMutators returns the mutators named in the comma separated list,
+// or the default ones if the list is empty.
+func enabledMutators(list, packs string) ([]mutator.Mutator, error) {
+	if list == "" && packs == "" {
+		return mutator.Defaults(), nil
+	}
+
+	var mutators []mutator.Mutator
+	enabled := map[string]bool{}
+	add := func(m mutator.Mutator) {
+		if !enabled[m.Name] {
+			enabled[m.Name] = true
+			mutators = append(mutators, m)
+		}
+	}
+
+	for _, name := range splitList(list) {
+		m, ok := mutator.Get(name)
+		if !ok {
+			err := fmt.Errorf("unknown mutator %q", name)
+			if suggestion := mutator.Suggest(name); suggestion != "" {
+				err = fmt.Errorf("unknown mutator %q, did you mean %s?", name, suggestion)
+			}
+			return nil, &ConfigError{Err: err}
+		}
+
+		add(m)
+	}
+
+	for _, name := range splitList(packs) {
+		members := mutator.Pack(name)
+		if len(members) == 0 {
+			return nil, &ConfigError{Err: fmt.Errorf("unknown pack %q, expected one of %s", name, strings.Join(mutator.Packs(), ", "))}
+		}
+
+		for _, m := range members {
+			add(m)
+		}
 	}
 
+	return mutators, nil
+}
+
+// splitList splits a comma separated flag value, ignoring blanks.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// makeMutationDir creates the directory for mutated files, overlays and
+// logs. It is taken from GOMUTATION or created as a temporary directory.
+func makeMutationDir() (string, error) {
 	mutationDir := os.Getenv(GOMUTATION)
 	if mutationDir == "" {
 		tmpDir, err := os.MkdirTemp("", "mutation")
 		if err != nil {
-			log.Fatalln(err)
+			return "", err
 		}
 
 		mutationDir = tmpDir
@@ -44,148 +546,448 @@ func main() {
 
 	err := os.MkdirAll(mutationDir, os.ModePerm)
 	if err != nil {
-		log.Fatalf("failed to create mutation directory: %s", err)
+		return "", &ConfigError{Err: fmt.Errorf("failed to create mutation directory: %s", err)}
 	}
 
-	log.Printf("mutation directory: %s", mutationDir)
-
-	filenames := os.Args[1:]
-	overlay, err := runMutations(filenames, mutationDir, os.Stdout)
+	// go test runs from the package directory, so paths in the overlay
+	// must not be relative to ours
+	mutationDir, err = filepath.Abs(mutationDir)
 	if err != nil {
-		log.Fatalf("failed to run mutations: %s", err)
+		return "", err
 	}
 
+	log.Printf("mutation directory: %s", mutationDir)
+
+	return mutationDir, nil
+}
+
+// testPackage mutates filenames, which must belong to the same package,
+// and runs the package tests against every mutant on its own. The user
+// overlay, if any, is applied to both the baseline and the mutated runs.
+func (r *runner) testPackage(filenames []string, mutationDir string) error {
 	absPath, err := filepath.Abs(filenames[0])
 	if err != nil {
-		log.Fatalln(err)
+		return &ConfigError{Err: err}
 	}
 	dir := filepath.Dir(absPath)
 
-	log.Printf("running go test on dir: %s", dir)
+	version, err := r.opts.toolchain.goVersion(dir)
+	if err != nil {
+		return err
+	}
 
-	tests, err := runGoTest(dir, overlay)
+	err = checkRoots(filenames, r.roots)
 	if err != nil {
-		log.Fatalf("error running go test: %s", err)
+		return err
 	}
 
-	testCount := 0
-	failed := 0
-	for _, test := range tests {
-		if test.Test == "" {
-			continue
+	err = checkOverlayConflicts(r.userOverlay, filenames)
+	if err != nil {
+		return err
+	}
+
+	result := report.Result{
+		Dir:       dir,
+		GoVersion: version,
+	}
+
+	result.AssertionFree, err = assertionFreeTests(r.opts.toolchain.buildContext(), dir)
+	if err != nil {
+		return fmt.Errorf("failed to scan tests: %s", err)
+	}
+
+	result.TestedBy, err = r.testedBy(dir)
+	if err != nil {
+		return err
+	}
+
+	r.opts.events.Emit(report.Event{Type: report.ScanStarted, Dir: dir, Files: filenames})
+
+	filenames, result.Skipped, err = scanFiles(r.opts.toolchain.buildContext(), filenames)
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %s", err)
+	}
+
+	if r.opts.strict && len(result.Skipped) > 0 {
+		var unmutable []string
+		for _, s := range result.Skipped {
+			unmutable = append(unmutable, fmt.Sprintf("%s (%s)", s.File, s.Reason))
 		}
+		return &ConfigError{Err: fmt.Errorf("strict: files can't be mutated: %s", strings.Join(unmutable, ", "))}
+	}
 
-		switch test.Action {
-		case "run":
-			fmt.Printf("=== RUN   %s\n", test.Test)
-		case "pass":
-			testCount++
-			fmt.Printf("--- PASS: %s (%0.2fs) - MUTATION NOT CAUGHT\n", test.Test, test.Elapsed)
-		case "fail":
-			testCount++
-			failed++
-			fmt.Printf("--- FAIL: %s (%0.2fs) - MUTATION CAUGHT\n", test.Test, test.Elapsed)
+	if r.opts.discover {
+		filenames, err = skipDefaults(filenames, &result.Skipped)
+		if err != nil {
+			return fmt.Errorf("failed to scan files: %s", err)
 		}
 	}
 
-	if failed != testCount {
-		fmt.Printf("FAIL\n%d out of %d tests didn't catch any mutations\n", testCount-failed, testCount)
-		os.Exit(1)
+	var targets funcSet
+	if r.opts.diff != "" {
+		targets, err = impactedFuncs(r.opts.toolchain.buildContext(), dir, r.opts.diff, r.opts.impactDepth, r.opts.strict)
+		if err != nil {
+			return fmt.Errorf("failed to find changed functions: %s", err)
+		}
+
+		filenames, err = splitTargeted(filenames, targets, reasonNotImpacted, &result.Skipped)
+		if err != nil {
+			return err
+		}
 	}
 
-	fmt.Println("PASS")
-}
+	if len(r.excludeFuncs) > 0 {
+		pkgPath, err := r.opts.toolchain.importPath(dir)
+		if err != nil {
+			return err
+		}
 
-type TestEvent struct {
-	Time    time.Time // encodes as an RFC3339-format string
-	Action  string
-	Package string
-	Test    string
-	Elapsed float64 // seconds
-	Output  string
-}
+		targets, err = excludeFuncs(pkgPath, filenames, targets, r.excludeFuncs)
+		if err != nil {
+			return fmt.Errorf("failed to scan files: %s", err)
+		}
 
-func parseGoTestOutput(test []byte) ([]TestEvent, error) {
-	var tests []TestEvent
-	list := "[" + strings.ReplaceAll(string(test[:len(test)-1]), "\n", ",") + "]"
-	err := json.Unmarshal([]byte(list), &tests)
+		filenames, err = splitTargeted(filenames, targets, reasonExcluded, &result.Skipped)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.opts.exported {
+		targets, err = exportedFuncs(r.opts.toolchain.buildContext(), dir, targets, r.opts.helpers, r.opts.strict)
+		if err != nil {
+			return fmt.Errorf("failed to find exported functions: %s", err)
+		}
+
+		filenames, err = splitTargeted(filenames, targets, reasonUnexported, &result.Skipped)
+		if err != nil {
+			return err
+		}
+	}
+
+	mutants, err := r.findMutants(filenames, targets)
 	if err != nil {
-		log.Printf("raw json: %s", test)
-		return nil, fmt.Errorf("error unmarshaling json: %s", err)
+		return fmt.Errorf("failed to scan files: %s", err)
+	}
+
+	if len(r.only) > 0 {
+		var selected []mutant
+		for _, mt := range mutants {
+			if r.only[mt.ID] {
+				r.onlyFound[mt.ID] = true
+				selected = append(selected, mt)
+			}
+		}
+		mutants = selected
+	}
+
+	if r.opts.since != "" {
+		mutants, err = sinceFilter(mutants, r.opts.since)
+		if err != nil {
+			return &ConfigError{Err: err}
+		}
 	}
-	return tests, nil
-}
 
-func runGoTest(pkgDir, overlay string) ([]TestEvent, error) {
-	out, err := exec.Command("go", "test", "--json", "--overlay", overlay, pkgDir).CombinedOutput()
+	// mutants asked for by ID are all tested
+	if r.preset.Sample > 0 && len(r.only) == 0 {
+		mutants = sample(mutants, r.preset.Sample, r.seed)
+	}
+
+	r.opts.events.Emit(report.Event{Type: report.ScanFinished, Dir: dir, Mutants: len(mutants)})
+
+	if len(mutants) == 0 {
+		// nothing to mutate, the tests would only repeat the baseline
+		return r.write(result)
+	}
+
+	if !r.skipBaseline {
+		err = r.baseline(dir, mutationDir, &result)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the baseline has downloaded the modules the tests need, mutants
+	// must not fetch any
+	r.opts.toolchain.offline = true
+	defer func() { r.opts.toolchain.offline = false }()
+
+	err = r.runMutants(dir, mutationDir, mutants, &result)
 	if err != nil {
-		// go test returns with exit code 1 if tests fail
-		// let's log just in case but move on
-		log.Println(err)
+		return err
+	}
+
+	if result.Count(report.BuildFailed) == len(result.Mutants) {
+		// a few mutants may not compile, but none at all points to a
+		// problem with the setup rather than the mutations
+		return &BuildError{Package: dir}
+	}
+
+	if r.opts.order == 2 {
+		err = r.runHigherOrder(dir, mutationDir, mutants, &result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.write(result)
+}
+
+// testFlags returns the go test flags of the regular runs: those of the
+// preset, keeping the expensive tests out.
+func (r *runner) testFlags() []string {
+	flags := append(slices.Clone(r.goTestFlags), r.preset.testFlags()...)
+	return append(flags, r.expensive.skipFlags()...)
+}
+
+// timeoutFlags returns the go test -timeout of the mutants of the package
+// in dir, long enough for tests as slow as those of the baseline, so that
+// only the mutants looping or blocking forever reach it and are killed by
+// the timeout. None if the baseline wasn't timed or the go test command
+// of exec has its own.
+func (r *runner) timeoutFlags(dir string) []string {
+	if slices.ContainsFunc(r.goTestFlags, func(f string) bool { return flagName(f) == "timeout" }) {
+		return nil
+	}
+
+	wall, ok := r.elapsed[dir]
+	if !ok {
+		wall = r.commandElapsed
+	}
+	if wall <= 0 {
+		return nil
 	}
 
-	return parseGoTestOutput(out)
+	// the baseline runs with a warm build cache, like the mutants, which
+	// only rebuild the package mutated; the margin is for that and for
+	// workers slowing each other
+	timeout := (timeoutFactor*wall + minTimeout).Round(time.Second)
+	log.Printf("%s: the baseline took %s, mutants time out after %s", dir, wall.Round(time.Millisecond), timeout)
+	return []string{"-timeout", timeout.String()}
 }
 
-func runMutations(filenames []string, mutationDir string, output io.Writer) (string, error) {
-	overlays := map[string]string{}
-	for _, filename := range filenames {
-		log.Printf("source file: %s", filename)
+// deep reports whether the expensive tests run on survivors.
+func (r *runner) deep() bool {
+	return r.preset.Expensive && r.expensive != nil
+}
 
-		fset := token.NewFileSet()
-		file, err := parser.ParseFile(fset, filename, nil, 0)
+// baseline runs the package tests without mutations, which must pass for
+// the mutation results to mean anything.
+func (r *runner) baseline(dir, mutationDir string, result *report.Result) error {
+	var overlay string
+	if len(r.userOverlay) > 0 {
+		var err error
+		overlay, err = writeOverlay(filepath.Join(mutationDir, "baseline-overlay.json"), r.userOverlay)
 		if err != nil {
-			return "", err
+			return err
 		}
+	}
 
-		astutil.Apply(file, nil, reverseIfCond)
-		mutatedFile := filepath.Join(mutationDir, filepath.Base(filename))
+	log.Printf("running baseline go test on dir: %s", dir)
 
-		log.Printf("mutated file: %s", mutatedFile)
-		f, err := os.Create(mutatedFile)
+	flags := append(r.testFlags(), result.TestedBy...)
+	profile := filepath.Join(mutationDir, "baseline.cover")
+	if r.coverage {
+		flags = append(flags, "-coverprofile="+profile)
+	}
+
+	r.opts.toolchain.warmCache(dir, overlay, flags, []string{"."})
+
+	logFile := filepath.Join(mutationDir, "baseline.log.gz")
+	tests, u, err := r.opts.toolchain.measureGoTest(dir, overlay, logFile, flags)
+	if err != nil {
+		return fmt.Errorf("error running go test: %w", err)
+	}
+
+	failedBuild, failedTests := failures(tests)
+	if failedBuild != "" || len(failedTests) > 0 {
+		return &BaselineError{FailedBuild: failedBuild, Failed: failedTests}
+	}
+
+	if r.deep() {
+		flags := append(r.preset.testFlags(), r.expensive.runFlags()...)
+		flags = append(flags, result.TestedBy...)
+		tests, err := r.opts.toolchain.runGoTest(dir, overlay, filepath.Join(mutationDir, "baseline-expensive.log.gz"), flags)
 		if err != nil {
-			return "", err
+			return fmt.Errorf("error running go test: %s", err)
+		}
+
+		failedBuild, failedTests := failures(tests)
+		if failedBuild != "" || len(failedTests) > 0 {
+			return &BaselineError{FailedBuild: failedBuild, Failed: failedTests}
 		}
-		defer f.Close()
+	}
+
+	r.elapsed[dir] = u.wall
+
+	// with docker only the client is measured
+	if r.opts.toolchain.docker == "" {
+		r.usage[dir] = u
+	}
 
-		printer.Fprint(f, fset, file)
-		overlays[filename] = mutatedFile
+	if _, err := os.Stat(profile); r.coverage && err == nil {
+		result.CoverProfile = profile
 	}
 
-	type ov struct {
-		Replace map[string]string
+	if a := r.opts.toolchain.adapter; a != nil && a.Format == "ginkgo" {
+		result.Focused = focusedSuites(adapterReport(logFile))
+	}
+
+	return nil
+}
+
+// write tallies the result of a package and sends it to the reports.
+func (r *runner) write(result report.Result) error {
+	r.survived += result.Count(report.Survived)
+	r.total += result.Count(report.Survived) + result.Count(report.Killed)
+
+	scope, min := r.thresholds.scope(displayPath(result.Dir))
+	if r.scores[scope] == nil {
+		r.scores[scope] = &scopeScore{Scope: scope, Threshold: min}
 	}
+	r.scores[scope].Killed += result.Count(report.Killed)
+	r.scores[scope].Total += result.Count(report.Survived) + result.Count(report.Killed)
 
-	bytes, err := json.Marshal(ov{Replace: overlays})
+	err := r.sink.Write(result)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to write report: %s", err)
 	}
 
-	overlay := filepath.Join(mutationDir, "overlay.json")
-	log.Printf("overlay file: %s", overlay)
+	return nil
+}
 
-	f, err := os.Create(overlay)
+// finish closes the reports and returns the verdict of the run.
+func (r *runner) finish() error {
+	err := r.sink.Close(r.metadata())
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to write report: %s", err)
+	}
+
+	if r.history != nil {
+		err = r.history.save(r.opts.history)
+		if err != nil {
+			return fmt.Errorf("failed to write history: %s", err)
+		}
+	}
+
+	var unknown []string
+	for id := range r.only {
+		if !r.onlyFound[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &ConfigError{Err: fmt.Errorf("unknown mutants: %s", strings.Join(unknown, ", "))}
+	}
+
+	if failed := failedScopes(r.scores); len(failed) > 0 {
+		err := &ThresholdError{Survived: r.survived, Total: r.total}
+		if len(r.thresholds) > 0 {
+			err.Scopes = failed
+		}
+		return err
 	}
-	fmt.Fprintf(f, "%s", bytes)
 
-	return overlay, nil
+	return nil
 }
 
-func reverseIfCond(c *astutil.Cursor) bool {
-	n := c.Node()
-	switch x := n.(type) {
-	case *ast.IfStmt:
-		bin, ok := x.Cond.(*ast.BinaryExpr)
-		if ok {
-			notBin := &ast.UnaryExpr{
-				Op: token.NOT,
-				X:  bin,
-			}
-			x.Cond = notBin
+type TestEvent struct {
+	Time        time.Time // encodes as an RFC3339-format string
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64 // seconds
+	Output      string
+	FailedBuild string
+}
+
+// failures returns the package that failed to build, if any, and the
+// names of the failed tests. A failed subtest fails its parent too, so
+// only the innermost failures are returned, as they tell which case of a
+// table-driven test or which method of a testify suite failed.
+func failures(tests []TestEvent) (string, []string) {
+	var failedBuild string
+	var failedTests []string
+	for _, test := range tests {
+		if test.Action != "fail" {
+			continue
+		}
+
+		switch {
+		case test.FailedBuild != "":
+			failedBuild = test.FailedBuild
+		case test.Test != "":
+			failedTests = append(failedTests, test.Test)
+		}
+	}
+	return failedBuild, innermost(failedTests)
+}
+
+// innermost drops the tests with a subtest among tests.
+func innermost(tests []string) []string {
+	var inner []string
+	for _, t := range tests {
+		hasSubtest := slices.ContainsFunc(tests, func(s string) bool {
+			return strings.HasPrefix(s, t+"/")
+		})
+		if !hasSubtest {
+			inner = append(inner, t)
+		}
+	}
+	return inner
+}
+
+// parseGoTestOutput decodes the events of go test -json. The output is
+// combined with stderr, so lines that aren't events, such as build errors
+// of older go versions, are skipped, as are line endings of any platform.
+func parseGoTestOutput(out []byte) ([]TestEvent, error) {
+	var tests []TestEvent
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		var event TestEvent
+		err := json.Unmarshal(line, &event)
+		if err != nil {
+			log.Printf("raw json: %s", line)
+			return nil, fmt.Errorf("error unmarshaling json: %s", err)
 		}
+		tests = append(tests, event)
+	}
+	return tests, nil
+}
+
+// goFlagsOverlay returns the overlay set with GOFLAGS, if any. Flags given
+// on the command line take precedence over GOFLAGS, so it would be lost
+// once selene passes its own overlay to go test.
+func goFlagsOverlay() string {
+	for _, f := range strings.Fields(os.Getenv("GOFLAGS")) {
+		f = strings.TrimPrefix(f, "-")
+		f = strings.TrimPrefix(f, "-")
+		if value, ok := strings.CutPrefix(f, "overlay="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// writeGoTestLog stores the raw go test output compressed, so a mutation
+// run can be diagnosed without running it again.
+func writeGoTestLog(filename string, out []byte) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write(out)
+	if err != nil {
+		return err
 	}
 
-	return true
+	return zw.Close()
 }
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// as without -v
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// maxRSS returns 0, as the peak memory of processes isn't known on this
+// platform.
+func maxRSS(*os.ProcessState) int64 {
+	return 0
+}
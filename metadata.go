@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// metadata collects the information about the run embedded in reports.
+// It is best effort: anything that can't be found is left empty.
+func (r *runner) metadata() report.Metadata {
+	m := report.Metadata{
+		Version: seleneVersion(),
+		Mode:    r.opts.mode,
+		Start:   r.start,
+		Seed:    r.seed,
+		Filters: report.Filters{
+			Diff:            r.opts.diff,
+			Since:           r.opts.since,
+			ExportedOnly:    r.opts.exported,
+			ExportedHelpers: r.opts.helpers,
+			Packs:           splitList(r.opts.packs),
+			Sample:          r.preset.Sample,
+			Order:           r.opts.order,
+		},
+		Host: report.Host{
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+			CPUs: runtime.NumCPU(),
+		},
+	}
+
+	for _, mut := range r.mutators {
+		m.Mutators = append(m.Mutators, mut.Name+"@"+mut.Version)
+	}
+
+	for id := range r.only {
+		m.Filters.Only = append(m.Filters.Only, id)
+	}
+	sort.Strings(m.Filters.Only)
+	if r.opts.diff != "" {
+		m.Filters.ImpactDepth = r.opts.impactDepth
+	}
+	for _, re := range r.excludeFuncs {
+		m.Filters.ExcludeFunctions = append(m.Filters.ExcludeFunctions, re.String())
+	}
+	if env := r.opts.toolchain.environment; env != nil {
+		m.Filters.Env = report.Env{Allow: env.Allow, Deny: env.Deny, Isolate: env.Isolate}
+	}
+
+	m.GoVersion, _ = r.opts.toolchain.goVersion(".")
+	m.Host.Name, _ = os.Hostname()
+
+	m.Git.Commit = git("rev-parse", "HEAD")
+	if m.Git.Commit != "" {
+		m.Git.Branch = git("rev-parse", "--abbrev-ref", "HEAD")
+		m.Git.Dirty = git("status", "--porcelain") != ""
+	}
+
+	m.Duration = time.Since(r.start)
+
+	return m
+}
+
+// seleneVersion returns the module version selene was built from, which
+// is "(devel)" for local builds.
+func seleneVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	return info.Main.Version
+}
+
+// git runs a git command in the current directory and returns its
+// trimmed output, or an empty string if it fails.
+func git(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
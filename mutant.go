@@ -0,0 +1,844 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/danicat/selene/internal/mutator"
+	"github.com/danicat/selene/internal/report"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// mutant is a single mutation of a source file. It is found by scanning
+// the file and applied later by parsing the file again and walking it the
+// same way, so it is identified by its position in the walk instead of
+// holding on to AST nodes.
+type mutant struct {
+	ID      string
+	File    string // absolute path
+	Pos     token.Position
+	Mutator mutator.Mutator
+	Index   int // candidate of the mutator within the file, in walk order
+	Variant int
+	Funcs   map[int]bool
+}
+
+// walkFuncs applies post to the whole file, or if funcs is not nil only to
+// the functions declared at those lines.
+func walkFuncs(fset *token.FileSet, file *ast.File, funcs map[int]bool, post astutil.ApplyFunc) {
+	if funcs == nil {
+		astutil.Apply(file, nil, post)
+		return
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if ok && funcs[fset.Position(fn.Name.Pos()).Line] {
+			astutil.Apply(fn, nil, post)
+		}
+	}
+}
+
+// findMutants returns the mutants of filenames, in file order. If targets
+// is not nil only the functions it contains are mutated.
+func (r *runner) findMutants(filenames []string, targets funcSet) ([]mutant, error) {
+	found := make([][]mutant, len(filenames))
+	errs := make([]error, len(filenames))
+
+	// files are independent from each other, so they are parsed and
+	// scanned concurrently, up to one per CPU
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, filename := range filenames {
+		wg.Add(1)
+		go func(i int, filename string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			absPath, err := filepath.Abs(filename)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			var funcs map[int]bool
+			if targets != nil {
+				funcs = targets[absPath]
+			}
+
+			found[i], errs[i] = cachedScan(r.opts.toolchain.buildContext(), absPath, r.mutators, funcs)
+		}(i, filename)
+	}
+	wg.Wait()
+
+	var mutants []mutant
+	for i := range filenames {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		mutants = append(mutants, found[i]...)
+	}
+
+	return mutants, nil
+}
+
+// scanMutants parses filename and returns a mutant for every variant of
+// every candidate of the mutators.
+func scanMutants(ctx *build.Context, filename string, mutators []mutator.Mutator, funcs map[int]bool) ([]mutant, error) {
+	log.Printf("source file: %s", filename)
+
+	// parsed as writeMutant does, so candidates are walked the same way;
+	// typed mutators share the parse and type info of the package, which
+	// candidates are only found in, not applied
+	var fset *token.FileSet
+	var file *ast.File
+	var info *types.Info
+	if needsTypes(mutators) {
+		pkg, err := checkPackage(ctx, filename)
+		if err != nil {
+			return nil, err
+		}
+		fset, file, info = pkg.fset, pkg.files[filename], pkg.info
+	} else {
+		fset = token.NewFileSet()
+		var err error
+		file, err = parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mutants []mutant
+	ids := map[string]int{}
+	for _, m := range mutators {
+		index := 0
+		walkFuncs(fset, file, funcs, func(c *astutil.Cursor) bool {
+			variants := m.Mutations(c, mutatorInfo(m, info))
+			if len(variants) == 0 {
+				return true
+			}
+
+			for v, variant := range variants {
+				pos := fset.Position(c.Node().Pos())
+				if variant.Pos.IsValid() {
+					pos = fset.Position(variant.Pos)
+				}
+
+				id := fmt.Sprintf("%s:%d:%d:%s", displayPath(filename), pos.Line, pos.Column, m.Name)
+				if len(variants) > 1 {
+					id += "/" + strconv.Itoa(v)
+				}
+				// nodes can share a position with their first child
+				ids[id]++
+				if ids[id] > 1 {
+					id += "~" + strconv.Itoa(ids[id])
+				}
+
+				mutants = append(mutants, mutant{
+					ID:      id,
+					File:    filename,
+					Pos:     pos,
+					Mutator: m,
+					Index:   index,
+					Variant: v,
+					Funcs:   funcs,
+				})
+			}
+			index++
+
+			return true
+		})
+	}
+
+	return mutants, nil
+}
+
+// usedImports returns the paths of the imports file uses, as far as
+// astutil.UsesImport can tell from their names.
+func usedImports(file *ast.File) []string {
+	var used []string
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err == nil && astutil.UsesImport(file, path) {
+			used = append(used, path)
+		}
+	}
+	return used
+}
+
+// mutatorInfo returns info if m needs types, nil otherwise.
+func mutatorInfo(m mutator.Mutator, info *types.Info) *types.Info {
+	if !m.Types {
+		return nil
+	}
+	return info
+}
+
+// displayPath returns filename relative to the current directory if it is
+// below it, as mutant IDs are meant to be typed back with --only.
+func displayPath(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filename
+	}
+
+	// Rel fails for paths on another volume on Windows
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filename
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// writeMutant applies the mutant to a fresh parse of its file and writes
+// the result to dir. It returns the overlay replacements of the mutant, the
+// path of the mutated file by original path, along with those of the other
+// files of the package changed by linked edits, and the source of the
+// mutated file before imports left unused are removed, which only differs
+// from the original where it was mutated. Comments are kept, as directives
+// such as go:embed and go:linkname live in them; the overlay makes the go
+// command read the copies as if they were the originals, so paths relative
+// to the package directory still resolve.
+func writeMutant(ctx *build.Context, mt mutant, dir string) (map[string]string, []byte, error) {
+	return writeMutants(ctx, []mutant{mt}, dir)
+}
+
+// misplacedError is the error of mutants not found at the positions they
+// were scanned at, as when the scan cache is stale.
+type misplacedError struct {
+	IDs []string
+}
+
+func (e *misplacedError) Error() string {
+	return fmt.Sprintf("mutants %s not found at their positions", strings.Join(e.IDs, ", "))
+}
+
+// writeMutants is writeMutant for several mutants of the same file, all
+// applied in a single walk. Each mutant must be found at its position,
+// which fails when an earlier one changed what later ones are counted on.
+func writeMutants(ctx *build.Context, mts []mutant, dir string) (map[string]string, []byte, error) {
+	filename := mts[0].File
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// types are checked before anything is mutated
+	var info *types.Info
+	var siblings []*ast.File
+	if slices.ContainsFunc(mts, func(mt mutant) bool { return mt.Mutator.Types }) {
+		info, siblings = typeInfo(ctx, fset, filename, file)
+	}
+
+	var mutators []mutator.Mutator
+	pending := map[string]int{} // mutants left to apply, by mutator
+	for _, mt := range mts {
+		if pending[mt.Mutator.Name] == 0 {
+			mutators = append(mutators, mt.Mutator)
+		}
+		pending[mt.Mutator.Name]++
+	}
+
+	files := map[string]*ast.File{filename: file}
+	used := map[*ast.File][]string{file: usedImports(file)}
+	for _, f := range siblings {
+		files[fset.File(f.Pos()).Name()] = f
+		used[f] = usedImports(f)
+	}
+
+	index := map[string]int{}
+	applied := make([]bool, len(mts))
+	var misplaced []string
+	var linked []mutator.Edit
+	walkFuncs(fset, file, mts[0].Funcs, func(c *astutil.Cursor) bool {
+		// candidates are all found before any is applied, as they
+		// would be on the original node
+		type candidate struct {
+			name     string
+			index    int
+			variants []mutator.Mutation
+		}
+		var candidates []candidate
+		for _, m := range mutators {
+			if pending[m.Name] == 0 {
+				continue
+			}
+			variants := m.Mutations(c, mutatorInfo(m, info))
+			if len(variants) > 0 {
+				candidates = append(candidates, candidate{m.Name, index[m.Name], variants})
+				index[m.Name]++
+			}
+		}
+
+		for _, cand := range candidates {
+			for i, mt := range mts {
+				if applied[i] || mt.Mutator.Name != cand.name || mt.Index != cand.index {
+					continue
+				}
+
+				variant := cand.variants[mt.Variant]
+				pos := fset.Position(c.Node().Pos())
+				if variant.Pos.IsValid() {
+					pos = fset.Position(variant.Pos)
+				}
+				if pos.Line != mt.Pos.Line || pos.Column != mt.Pos.Column {
+					misplaced = append(misplaced, mt.ID)
+				}
+
+				variant.Apply()
+				linked = append(linked, variant.Linked...)
+				applied[i] = true
+				pending[mt.Mutator.Name]--
+			}
+		}
+
+		return true
+	})
+
+	for i, mt := range mts {
+		if !applied[i] {
+			return nil, nil, fmt.Errorf("mutant %s not found, was %s modified?", mt.ID, mt.File)
+		}
+	}
+	if len(misplaced) > 0 {
+		return nil, nil, &misplacedError{IDs: misplaced}
+	}
+
+	// linked edits are made once the walk is over, as those of the
+	// mutated file would change what later mutants are counted on
+	changed := []string{filename}
+	for _, edit := range linked {
+		name := fset.File(edit.Pos).Name()
+		if files[name] == nil {
+			return nil, nil, fmt.Errorf("linked edit of %s outside the package of %s", name, filename)
+		}
+		edit.Apply()
+		if !slices.Contains(changed, name) {
+			changed = append(changed, name)
+		}
+	}
+
+	var mutated bytes.Buffer
+	err = format.Node(&mutated, fset, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replaced := map[string]string{}
+	for _, name := range changed {
+		f := files[name]
+
+		// mutations removing code may remove the last use of an import
+		for _, path := range used[f] {
+			if !astutil.UsesImport(f, path) {
+				astutil.DeleteImport(fset, f, path)
+			}
+		}
+
+		replaced[name], err = writeFile(fset, f, filepath.Join(dir, filepath.Base(name)))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return replaced, mutated.Bytes(), nil
+}
+
+// writeFile writes a mutated file to path, formatted as gofmt would, so it
+// only differs from a gofmt'ed original where it was mutated.
+func writeFile(fset *token.FileSet, file *ast.File, path string) (string, error) {
+	log.Printf("mutated file: %s", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	err = format.Node(f, fset, file)
+	if err != nil {
+		return "", err
+	}
+
+	return path, f.Close()
+}
+
+// runMutants tests the mutants of the package in pkgDir concurrently and
+// adds them to result, in the order they were found. The reports are
+// updated as each one finishes.
+func (r *runner) runMutants(pkgDir, mutationDir string, mutants []mutant, result *report.Result) error {
+	workers, concurrencyFlags := r.concurrency(pkgDir)
+	testFlags := append(r.testFlags(), concurrencyFlags...)
+	testFlags = append(testFlags, r.timeoutFlags(pkgDir)...)
+	// go test takes packages among the flags, helper packages are
+	// tested by the packages whose tests import them
+	testFlags = append(testFlags, result.TestedBy...)
+
+	var expensiveFlags []string
+	if r.deep() {
+		expensiveFlags = append(r.preset.testFlags(), concurrencyFlags...)
+		expensiveFlags = append(expensiveFlags, r.expensive.runFlags()...)
+		expensiveFlags = append(expensiveFlags, result.TestedBy...)
+	}
+
+	done := make([]report.Mutant, len(mutants))
+	errs := make([]error, len(mutants))
+
+	var mu sync.Mutex
+	progress := *result
+	var progressErr error
+
+	// mutants are tested one by one unless --batch groups them, which
+	// takes the calls between their functions
+	var graph map[*types.Func]*callNode
+	if r.opts.batch > 1 && len(mutants) > 0 {
+		var err error
+		graph, err = buildCallGraph(r.opts.toolchain.buildContext(), filepath.Dir(mutants[0].File), false)
+		if err != nil {
+			log.Printf("call graph of %s: %s, testing its mutants one by one", filepath.Dir(mutants[0].File), err)
+		}
+	}
+	groups := batches(mutants, r.opts.batch, graph)
+	funcs := enclosingFuncs(mutants)
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for g, group := range groups {
+		wg.Add(1)
+		go func(g int, group []int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, i := range group {
+				mt := mutants[i]
+				r.opts.events.Emit(report.Event{Type: report.MutantStarted, Mutant: &report.Mutant{
+					ID:      mt.ID,
+					File:    mt.File,
+					Line:    mt.Pos.Line,
+					Column:  mt.Pos.Column,
+					Mutator: mt.Mutator.Name,
+					Version: mt.Mutator.Version,
+				}})
+			}
+
+			// mutated files are named after the originals, so each
+			// mutant gets its own directory
+			dir := func(i int) string {
+				return filepath.Join(mutationDir, strconv.Itoa(i+1))
+			}
+
+			tested := false
+			if len(group) > 1 {
+				mts := make([]mutant, len(group))
+				dirs := make([]string, len(group))
+				for j, i := range group {
+					mts[j], dirs[j] = mutants[i], dir(i)
+				}
+
+				survivors, err := r.runBatch(pkgDir, mts, filepath.Join(mutationDir, "batch"+strconv.Itoa(g+1)), dirs, testFlags)
+				if err != nil {
+					errs[group[0]] = err
+					return
+				}
+				if survivors != nil {
+					for j, i := range group {
+						done[i] = survivors[j]
+					}
+					tested = true
+				}
+			}
+
+			for _, i := range group {
+				if !tested {
+					done[i], errs[i] = r.runMutant(pkgDir, mutants[i], dir(i), testFlags, expensiveFlags)
+					if errs[i] != nil {
+						return
+					}
+				}
+				done[i].Func = funcs[i]
+				r.opts.events.Emit(report.Event{Type: report.MutantFinished, Mutant: &done[i]})
+
+				mu.Lock()
+				progress.Mutants = append(progress.Mutants, done[i])
+				if p, ok := r.sink.(report.Progress); ok && progressErr == nil {
+					progressErr = p.Progress(progress)
+				}
+				mu.Unlock()
+			}
+		}(g, group)
+	}
+	wg.Wait()
+
+	for i, mt := range mutants {
+		if errs[i] != nil {
+			return fmt.Errorf("mutant %s: %s", mt.ID, errs[i])
+		}
+	}
+
+	if progressErr != nil {
+		return fmt.Errorf("failed to write report: %s", progressErr)
+	}
+
+	result.Mutants = append(result.Mutants, done...)
+	return nil
+}
+
+// runMutant runs the package tests with the mutant applied, and then the
+// expensive tests if it survives and expensiveFlags are set. Its files,
+// overlay and go test log are kept in dir so it can be reproduced.
+func (r *runner) runMutant(pkgDir string, mt mutant, dir string, testFlags, expensiveFlags []string) (report.Mutant, error) {
+	result, err := r.prepareMutant(mt, dir)
+	if err != nil {
+		return result, err
+	}
+
+	log.Printf("running go test for mutant %s", mt.ID)
+
+	var tests []TestEvent
+	if r.history != nil {
+		testFlags = append(slices.Clip(testFlags), "-failfast")
+
+		if pattern := r.history.killers(displayPath(mt.File)); pattern != "" {
+			// the tests that killed mutants of this file before are the
+			// most likely to kill this one too, the whole package only
+			// runs if they don't
+			logFile := filepath.Join(dir, "gotest-killers.log.gz")
+			tests, err = r.opts.toolchain.runGoTest(pkgDir, result.Overlay, logFile, append(slices.Clip(testFlags), r.opts.toolchain.selectTests(pattern)...))
+			if err != nil {
+				return result, fmt.Errorf("error running go test: %s", err)
+			}
+
+			failedBuild, failedTests := failures(tests)
+			if failedBuild != "" || len(failedTests) > 0 || packageFailed(tests) {
+				result.Log = logFile
+			} else {
+				tests = nil
+			}
+		}
+	}
+
+	if tests == nil {
+		tests, err = r.opts.toolchain.runGoTest(pkgDir, result.Overlay, result.Log, testFlags)
+		if err != nil {
+			return result, fmt.Errorf("error running go test: %s", err)
+		}
+	}
+
+	result.Repro = r.reproCommand(mt.ID)
+	result.GoTest = r.opts.toolchain.testCommand(pkgDir, result.Overlay, testFlags)
+
+	result.Status, result.KilledBy = verdict(tests)
+	if result.Status == report.Killed {
+		result.Cause = killCause(tests)
+	}
+
+	if result.Status == report.Survived && expensiveFlags != nil {
+		logFile := filepath.Join(dir, "gotest-expensive.log.gz")
+		tests, err := r.opts.toolchain.runGoTest(pkgDir, result.Overlay, logFile, expensiveFlags)
+		if err != nil {
+			return result, fmt.Errorf("error running go test: %s", err)
+		}
+
+		if status, killedBy := verdict(tests); status != report.Survived {
+			result.Status, result.KilledBy = status, killedBy
+			result.Cause = killCause(tests)
+			result.Tier = report.ExpensiveTier
+			result.Log = logFile
+			result.GoTest = r.opts.toolchain.testCommand(pkgDir, result.Overlay, expensiveFlags)
+		}
+	}
+
+	r.settle(mt, &result, tests)
+	return result, nil
+}
+
+// prepareMutant writes the mutated file and the overlay of the mutant to
+// dir, and returns its result before testing it.
+func (r *runner) prepareMutant(mt mutant, dir string) (report.Mutant, error) {
+	result := report.Mutant{
+		ID:      mt.ID,
+		File:    mt.File,
+		Line:    mt.Pos.Line,
+		Column:  mt.Pos.Column,
+		Mutator: mt.Mutator.Name,
+		Version: mt.Mutator.Version,
+		Log:     filepath.Join(dir, "gotest.log.gz"),
+		Overlay: filepath.Join(dir, "overlay.json"),
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return result, err
+	}
+
+	replaced, mutated, err := writeMutant(r.opts.toolchain.buildContext(), mt, dir)
+	if errors.As(err, new(*misplacedError)) {
+		// the scan cache was stale, as when a dependency changed
+		mt, err = r.rescanned(mt)
+		if err != nil {
+			return result, err
+		}
+		replaced, mutated, err = writeMutant(r.opts.toolchain.buildContext(), mt, dir)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	merged, err := mergeOverlays(r.userOverlay, replaced)
+	if err != nil {
+		return result, err
+	}
+	_, err = writeOverlay(result.Overlay, merged)
+	if err != nil {
+		return result, err
+	}
+
+	before, err := os.ReadFile(mt.File)
+	if err != nil {
+		return result, err
+	}
+	// the mutated file is formatted, so is the original it is compared to
+	if formatted, err := format.Source(before); err == nil {
+		before = formatted
+	}
+	result.Before, result.After = mutatedLines(before, mutated)
+
+	return result, nil
+}
+
+// rescanned returns mt as found by scanning its file again, once it
+// wasn't found at its position. The file is scanned once for all its
+// mutants, and its scan cache entry replaced.
+func (r *runner) rescanned(mt mutant) (mutant, error) {
+	r.rescanMu.Lock()
+	defer r.rescanMu.Unlock()
+
+	mutants, ok := r.rescans[mt.File]
+	if !ok {
+		var err error
+		mutants, err = rescan(r.opts.toolchain.buildContext(), mt.File, r.mutators, mt.Funcs)
+		if err != nil {
+			return mt, err
+		}
+		if r.rescans == nil {
+			r.rescans = map[string][]mutant{}
+		}
+		r.rescans[mt.File] = mutants
+	}
+
+	for _, found := range mutants {
+		if found.ID == mt.ID {
+			return found, nil
+		}
+	}
+	return mt, fmt.Errorf("mutant %s not found, was %s modified?", mt.ID, mt.File)
+}
+
+// settle records the verdict of a tested mutant in the history, and sets
+// how long the tests that decided it took.
+func (r *runner) settle(mt mutant, result *report.Mutant, tests []TestEvent) {
+	// expensive tests don't run in the regular runs the history selects
+	// tests for
+	if r.history != nil && result.Status == report.Killed && result.Tier == "" {
+		r.history.record(displayPath(mt.File), result.KilledBy)
+	}
+
+	// mutants not tested, as with --only, keep their age
+	if r.history != nil {
+		key := mt.ID + "@" + mt.Mutator.Version
+		if result.Status == report.Survived {
+			s := r.history.survived(key, r.start, r.commit)
+			if s.Since.Before(r.start) {
+				result.SurvivingSince = &s.Since
+				result.SurvivingSinceCommit = s.Commit
+			}
+		} else {
+			r.history.forget(key)
+		}
+	}
+
+	for _, test := range tests {
+		if test.Test == "" && (test.Action == "pass" || test.Action == "fail") {
+			result.Elapsed = test.Elapsed
+		}
+	}
+}
+
+// verdict returns the status of a mutant from the results of its tests,
+// and the tests that killed it.
+func verdict(tests []TestEvent) (report.Status, []string) {
+	failedBuild, failedTests := failures(tests)
+	switch {
+	case failedBuild != "":
+		return report.BuildFailed, nil
+	case len(failedTests) > 0 || packageFailed(tests):
+		return report.Killed, failedTests
+	}
+	return report.Survived, nil
+}
+
+// killCause returns report.TimeoutCause if the tests killing a mutant
+// timed out, report.PanicCause if they only failed by panicking or
+// crashing, and report.AssertionCause otherwise. Panics are reported as
+// output of the top-level test running them, so failed subtests of a
+// panicking test count as panicking too.
+func killCause(tests []TestEvent) string {
+	panicked := map[string]bool{}
+	for _, test := range tests {
+		if test.Action != "output" {
+			continue
+		}
+		if strings.HasPrefix(test.Output, "panic: test timed out") {
+			return report.TimeoutCause
+		}
+		if strings.HasPrefix(test.Output, "panic: ") || strings.HasPrefix(test.Output, "fatal error: ") {
+			top, _, _ := strings.Cut(test.Test, "/")
+			panicked[top] = true
+		}
+	}
+	if len(panicked) == 0 {
+		return report.AssertionCause
+	}
+
+	_, failedTests := failures(tests)
+	for _, name := range failedTests {
+		top, _, _ := strings.Cut(name, "/")
+		if !panicked[top] {
+			return report.AssertionCause
+		}
+	}
+	return report.PanicCause
+}
+
+// packageFailed reports whether the package failed outside of any test,
+// for example by exiting from an init function.
+func packageFailed(tests []TestEvent) bool {
+	for _, test := range tests {
+		if test.Action == "fail" && test.Test == "" && test.FailedBuild == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// reproCommand returns the selene command running only the given mutant,
+// with the settings of the current run.
+func (r *runner) reproCommand(id string) string {
+	args := []string{"selene", "run"}
+	if r.runAll && !r.opts.discover {
+		args[1] = "run-all"
+	}
+
+	if r.opts.mode != "full" {
+		args = append(args, "--mode", r.opts.mode)
+	}
+	if r.opts.overlay != "" {
+		args = append(args, "--overlay", r.opts.overlay)
+	}
+	if r.opts.config != defaultConfig {
+		args = append(args, "--config", r.opts.config)
+	}
+	tc := r.opts.toolchain
+	for _, f := range [][2]string{{"--go", tc.goBin}, {"--goos", tc.goos}, {"--goarch", tc.goarch}, {"--exec", tc.exec}} {
+		if f[1] != "" && !(f[0] == "--go" && f[1] == "go") {
+			args = append(args, f[0], f[1])
+		}
+	}
+	if tc.docker != "" {
+		args = append(args, "--docker")
+	}
+	if r.opts.mutators != "" {
+		args = append(args, "--mutators", r.opts.mutators)
+	}
+	if r.opts.packs != "" {
+		args = append(args, "--packs", r.opts.packs)
+	}
+	args = append(args, "--only", id)
+
+	if !r.runAll {
+		args = append(args, r.filenames...)
+	}
+
+	return shellJoin(args)
+}
+
+// testCommand returns the go test invocation reproducing a mutant by hand
+// with its overlay, for the shell of the platform.
+func (tc toolchain) testCommand(pkgDir, overlay string, testFlags []string) string {
+	if tc.adapter != nil {
+		args := tc.testArgs(overlay, testFlags)[1:]
+		command := tc.adapter.expand(args, adapterReport(filepath.Join(filepath.Dir(overlay), "gotest.log.gz")))
+		return shellCommand(pkgDir, tc.env(), command)
+	}
+	if tc.docker != "" {
+		return shellJoin(tc.dockerArgs(pkgDir, tc.testArgs(overlay, testFlags)...))
+	}
+
+	args := append([]string{tc.goBin}, tc.testArgs(overlay, testFlags)...)
+	return shellCommand(pkgDir, tc.env(), args)
+}
+
+// shellCommand returns the command line running args in dir with the
+// variables of env set. sh takes them before the command; cmd.exe has no
+// such syntax, so they are set first, and cd needs /d to change drives.
+func shellCommand(dir string, env, args []string) string {
+	if runtime.GOOS == "windows" {
+		line := "cd /d " + shellQuote(dir)
+		for _, v := range env {
+			// quoted, so the space before && isn't part of the value
+			line += ` && set "` + v + `"`
+		}
+		return line + " && " + shellJoin(args)
+	}
+
+	return "cd " + shellQuote(dir) + " && " + shellJoin(append(env, args...))
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes s for the shell of the platform, sh or cmd.exe, if it
+// contains anything but safe characters.
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		// cmd.exe only knows double quotes, and backslashes in paths
+		// need no quoting
+		if s == "" || strings.ContainsAny(s, " \t&|<>^()") {
+			return `"` + s + `"`
+		}
+		return s
+	}
+
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,~+@%", r))
+	}) < 0
+	if safe {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
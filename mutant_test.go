@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("quotes for sh")
+	}
+
+	tests := map[string]string{
+		"go":                     "go",
+		"-overlay=/tmp/x/o.json": "-overlay=/tmp/x/o.json",
+		"":                       "''",
+		"with space":             "'with space'",
+		"$HOME":                  "'$HOME'",
+		"it's":                   `'it'\''s'`,
+		"-run=^TestX$":           "'-run=^TestX$'",
+		"GOFLAGS=-mod=mod -v":    "'GOFLAGS=-mod=mod -v'",
+		"user@host:~/a+b,c%d.go": "user@host:~/a+b,c%d.go",
+		"semi;colon":             "'semi;colon'",
+	}
+
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	env := []string{"GOOS=linux", "GOARCH=arm64"}
+	args := []string{"go", "test", "-overlay=o.json", "."}
+
+	want := "cd /src/pkg && GOOS=linux GOARCH=arm64 go test -overlay=o.json ."
+	if runtime.GOOS == "windows" {
+		want = `cd /d /src/pkg && set "GOOS=linux" && set "GOARCH=arm64" && go test -overlay=o.json .`
+	}
+	if got := shellCommand("/src/pkg", env, args); got != want {
+		t.Errorf("shellCommand() = %s, want %s", got, want)
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(filepath.Dir(wd), "other", "a.go")
+
+	tests := map[string]string{
+		filepath.Join(wd, "a.go"):               "a.go",
+		filepath.Join(wd, "internal", "x.go"):   "internal/x.go",
+		outside:                                 outside,
+		filepath.Join(filepath.Dir(wd), "a.go"): filepath.Join(filepath.Dir(wd), "a.go"),
+	}
+
+	for filename, want := range tests {
+		if got := displayPath(filename); got != want {
+			t.Errorf("displayPath(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// overlayFile is the format of go build -overlay files.
+type overlayFile struct {
+	Replace map[string]string
+}
+
+// readOverlay loads a user provided overlay. Relative paths are resolved
+// against the current directory, as the go command would do, because go
+// test runs from the package directory instead.
+func readOverlay(filename string) (map[string]string, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("failed to read overlay: %s", err)}
+	}
+
+	var ov overlayFile
+	err = json.Unmarshal(bytes, &ov)
+	if err != nil {
+		return nil, &ConfigError{Err: fmt.Errorf("invalid overlay %s: %s", filename, err)}
+	}
+
+	replace := map[string]string{}
+	for from, to := range ov.Replace {
+		absFrom, err := filepath.Abs(from)
+		if err != nil {
+			return nil, err
+		}
+
+		// an empty path means the file is deleted in the overlay
+		absTo := to
+		if to != "" {
+			absTo, err = filepath.Abs(to)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		replace[absFrom] = absTo
+	}
+
+	return replace, nil
+}
+
+// checkOverlayConflicts reports source files that are also replaced by the
+// user overlay. Mutations are applied to the file on disk, so they would
+// silently drop the replacement. It is checked for the files to mutate
+// before they are scanned, and for every file a mutant writes, as linked
+// edits change other files of the package too.
+func checkOverlayConflicts(userOverlay map[string]string, filenames []string) error {
+	var conflicts []string
+	for _, filename := range filenames {
+		absPath, err := filepath.Abs(filename)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := userOverlay[absPath]; ok {
+			conflicts = append(conflicts, absPath)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return &ConfigError{Err: fmt.Errorf("files replaced by the overlay can't be mutated: %s", strings.Join(conflicts, ", "))}
+	}
+
+	return nil
+}
+
+// mergeOverlays combines the user overlay with the mutated files, none of
+// which may be replaced by the user overlay.
+func mergeOverlays(userOverlay, mutations map[string]string) (map[string]string, error) {
+	var written []string
+	for from := range mutations {
+		written = append(written, from)
+	}
+	err := checkOverlayConflicts(userOverlay, written)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]string{}
+	for from, to := range userOverlay {
+		merged[from] = to
+	}
+	for from, to := range mutations {
+		merged[from] = to
+	}
+	return merged, nil
+}
+
+// writeOverlay writes an overlay file for go test and returns its path.
+func writeOverlay(filename string, replace map[string]string) (string, error) {
+	bytes, err := json.Marshal(overlayFile{Replace: replace})
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(filename, bytes, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
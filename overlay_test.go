@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadOverlay(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "overlay.json")
+	err := os.WriteFile(filename, []byte(`{"Replace": {"a.go": "gen/a.go", "b.go": ""}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replace, err := readOverlay(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// relative paths are those of the current directory, not the
+	// overlay's
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		filepath.Join(wd, "a.go"): filepath.Join(wd, "gen", "a.go"),
+		filepath.Join(wd, "b.go"): "",
+	}
+	if len(replace) != len(want) {
+		t.Fatalf("readOverlay() = %v, want %v", replace, want)
+	}
+	for from, to := range want {
+		if got, ok := replace[from]; !ok || got != to {
+			t.Errorf("readOverlay() replaces %s with %q, want %q", from, got, to)
+		}
+	}
+}
+
+func TestReadOverlayInvalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "overlay.json")
+	err := os.WriteFile(filename, []byte(`{"Replace": [`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var configErr *ConfigError
+	if _, err := readOverlay(filename); !errors.As(err, &configErr) {
+		t.Errorf("readOverlay() error = %v, want a ConfigError", err)
+	}
+}
+
+func TestWriteOverlay(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "pkg", "a.go")
+	to := filepath.Join(dir, "mutation", "0", "a.go")
+
+	filename, err := writeOverlay(filepath.Join(dir, "overlay.json"), map[string]string{from: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ov overlayFile
+	if err := json.Unmarshal(data, &ov); err != nil {
+		t.Fatal(err)
+	}
+	// the go command takes the paths as they are, with the separators
+	// of the platform
+	if got := ov.Replace[from]; got != to {
+		t.Errorf("overlay replaces %s with %q, want %q", from, got, to)
+	}
+}
+
+func TestMergeOverlays(t *testing.T) {
+	user := map[string]string{"/a.go": "/gen/a.go"}
+	mutations := map[string]string{"/b.go": "/mutation/b.go"}
+
+	merged, err := mergeOverlays(user, mutations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"/a.go": "/gen/a.go", "/b.go": "/mutation/b.go"}
+	for from, to := range want {
+		if merged[from] != to {
+			t.Errorf("merged overlay replaces %s with %q, want %q", from, merged[from], to)
+		}
+	}
+	if len(user) != 1 {
+		t.Error("mergeOverlays changed the user overlay")
+	}
+
+	// linked edits write files of the package besides the mutated one
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user = map[string]string{filepath.Join(wd, "a.go"): filepath.Join(wd, "gen.go")}
+	mutations = map[string]string{filepath.Join(wd, "b.go"): "/mutation/b.go", filepath.Join(wd, "a.go"): "/mutation/a.go"}
+
+	var configErr *ConfigError
+	_, err = mergeOverlays(user, mutations)
+	if !errors.As(err, &configErr) {
+		t.Errorf("mergeOverlays() of a file replaced by the user overlay = %v, want a ConfigError", err)
+	}
+}
+
+func TestCheckOverlayConflicts(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := map[string]string{filepath.Join(wd, "a.go"): filepath.Join(wd, "gen.go")}
+
+	if err := checkOverlayConflicts(user, []string{"b.go"}); err != nil {
+		t.Errorf("checkOverlayConflicts(b.go) = %v, want nil", err)
+	}
+
+	var configErr *ConfigError
+	if err := checkOverlayConflicts(user, []string{"b.go", "a.go"}); !errors.As(err, &configErr) {
+		t.Errorf("checkOverlayConflicts(a.go) = %v, want a ConfigError", err)
+	}
+}
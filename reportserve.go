@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// reportCommand runs the selene report subcommands: serve, tune and
+// convert.
+func reportCommand(args []string) error {
+	if len(args) > 0 && args[0] == "tune" {
+		return tune(args[1:])
+	}
+	if len(args) > 0 && args[0] == "convert" {
+		return convert(args[1:])
+	}
+	if len(args) == 0 || args[0] != "serve" {
+		return &ConfigError{Err: fmt.Errorf("usage: selene report serve [-addr localhost:8000] <report.json>\n       selene report tune [-format text|json] <report.json>...\n       selene report convert [-to %d] [-o file] <report.json>", report.SchemaVersion)}
+	}
+
+	fs := flag.NewFlagSet("report serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8000", "`address` to serve the report on")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if fs.NArg() != 1 {
+		return &ConfigError{Err: fmt.Errorf("usage: selene report serve [-addr localhost:8000] <report.json>")}
+	}
+	filename := fs.Arg(0)
+
+	// fail early on a missing or invalid report rather than on the first
+	// request
+	_, err = report.ReadLiveDocument(filename)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	return serve(*addr, filename, reportHandler(filename))
+}
+
+// reportHandler serves the HTML report of filename, read again on every
+// request so it follows a run still writing it, and opens the file of a
+// mutant in the editor when asked to.
+func reportHandler(filename string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		doc, err := report.ReadLiveDocument(filename)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		q := req.URL.Query()
+		filter := report.Filter{File: q.Get("file"), Mutator: q.Get("mutator"), Status: q.Get("status")}
+		err = report.RenderServedHTML(w, doc, filter)
+		if err != nil {
+			log.Printf("failed to render report: %s", err)
+		}
+	})
+
+	// posted, so that other pages can't open files just by linking here,
+	// and from the report itself, as any page can post a form here too
+	mux.HandleFunc("/open", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !sameOrigin(req) {
+			http.Error(w, "cross-origin request", http.StatusForbidden)
+			return
+		}
+
+		doc, err := report.ReadLiveDocument(filename)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// only files of the report can be opened
+		id := req.FormValue("id")
+		m, ok := findMutant(doc, id)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown mutant %q", id), http.StatusNotFound)
+			return
+		}
+
+		err = openInEditor(m.File, m.Line)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		back := req.Referer()
+		if back == "" {
+			back = "/"
+		}
+		http.Redirect(w, req, back, http.StatusSeeOther)
+	})
+
+	return mux
+}
+
+// sameOrigin reports whether req comes from a page of the server, as told
+// by Sec-Fetch-Site or, in browsers not sending it, by Origin. The host
+// must be a loopback one, as Origin is only compared with the Host the
+// client sent, which DNS rebinding controls, and editors are only opened
+// for the local user. Requests with neither header are rejected.
+func sameOrigin(req *http.Request) bool {
+	if !isLoopback(req.Host) {
+		return false
+	}
+	if site := req.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin"
+	}
+	if origin := req.Header.Get("Origin"); origin != "" {
+		return origin == "http://"+req.Host
+	}
+	return false
+}
+
+// isLoopback reports whether host, with or without a port, is localhost
+// or a loopback address.
+func isLoopback(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func findMutant(doc report.Document, id string) (report.Mutant, bool) {
+	for _, r := range doc.Results {
+		for _, m := range r.Mutants {
+			if m.ID == id {
+				return m, true
+			}
+		}
+	}
+	return report.Mutant{}, false
+}
+
+// openInEditor opens file at line in $VISUAL or $EDITOR, which may include
+// arguments. Terminal editors take over the terminal selene runs in.
+func openInEditor(file string, line int) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return errors.New("set $EDITOR to open files")
+	}
+
+	// most editors take +line before the file, the graphical ones a
+	// file:line argument
+	args := fields[1:]
+	switch strings.TrimSuffix(filepath.Base(fields[0]), ".exe") {
+	case "code", "code-insiders", "codium", "cursor":
+		args = append(args, "-g", file+":"+strconv.Itoa(line))
+	case "subl", "zed":
+		args = append(args, file+":"+strconv.Itoa(line))
+	default:
+		args = append(args, "+"+strconv.Itoa(line), file)
+	}
+
+	cmd := exec.Command(fields[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start editor: %s", err)
+	}
+
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		want    bool
+	}{
+		{"same site", "localhost:8000", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://localhost:8000"}, true},
+		{"cross site", "localhost:8000", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "http://localhost:8000"}, false},
+		{"same site, other port", "localhost:8000", map[string]string{"Sec-Fetch-Site": "same-site"}, false},
+		{"same origin", "localhost:8000", map[string]string{"Origin": "http://localhost:8000"}, true},
+		{"loopback address", "127.0.0.1:8000", map[string]string{"Origin": "http://127.0.0.1:8000"}, true},
+		{"loopback ipv6", "[::1]:8000", map[string]string{"Sec-Fetch-Site": "same-origin"}, true},
+		{"other origin", "localhost:8000", map[string]string{"Origin": "http://example.com"}, false},
+		{"opaque origin", "localhost:8000", map[string]string{"Origin": "null"}, false},
+		{"rebound host", "example.com:8000", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://example.com:8000"}, false},
+		{"network address", "192.168.1.2:8000", map[string]string{"Origin": "http://192.168.1.2:8000"}, false},
+		{"not a browser", "localhost:8000", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://"+tt.host+"/open", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := sameOrigin(req); got != tt.want {
+				t.Errorf("sameOrigin() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allowedRoots returns the directories files may be mutated in: the module
+// of the working directory, or its workspace, and the extra ones given,
+// with symlinks resolved.
+func allowedRoots(extra []string) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, dir := range append([]string{moduleRoot(wd)}, extra...) {
+		root, err := resolve(dir)
+		if err != nil {
+			return nil, &ConfigError{Err: fmt.Errorf("invalid root %s: %s", dir, err)}
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// checkRoots returns an error unless every file, once symlinks are
+// resolved, is inside one of roots. Mutating a file through a symlink or
+// an absolute path could otherwise change sources other modules build
+// from, as those of the module cache or of GOPATH.
+func checkRoots(filenames, roots []string) error {
+	for _, filename := range filenames {
+		path, err := resolve(filename)
+		if err != nil {
+			return &ConfigError{Err: err}
+		}
+		if within(path, roots) {
+			continue
+		}
+		where := "is"
+		if abs, _ := filepath.Abs(filename); abs != path {
+			where = "resolves to " + path + ","
+		}
+		return &ConfigError{Err: fmt.Errorf("%s %s outside the module root %s; use --allow-root to mutate files elsewhere", filename, where, roots[0])}
+	}
+	return nil
+}
+
+// resolve returns the absolute path of a file with symlinks resolved.
+func resolve(filename string) (string, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+// within reports whether path is one of roots or inside one of them.
+func within(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goPackage is a directory with Go files and tests, within a module.
+type goPackage struct {
+	Module string
+	Dir    string
+	Files  []string // non-test Go files, the mutation targets
+	Tested bool     // has tests of its own, helpers may not
+}
+
+// runAll discovers every module under root and tests each of their
+// packages in turn, merging the results into a single verdict.
+func runAll(opts options, root string) error {
+	r, err := newRunner(opts)
+	if err != nil {
+		return err
+	}
+	r.runAll = true
+
+	pkgs, orphans, err := findPackages(root)
+	if err != nil {
+		return err
+	}
+
+	// as with ./..., nested modules are left out
+	if opts.discover {
+		pkgs = slices.DeleteFunc(pkgs, func(pkg goPackage) bool { return pkg.Module != root })
+	}
+
+	if opts.strict && len(orphans) > 0 {
+		return &ConfigError{Err: fmt.Errorf("strict: tested packages outside of any module: %s", strings.Join(orphans, ", "))}
+	}
+
+	if !slices.ContainsFunc(pkgs, func(pkg goPackage) bool { return pkg.Tested }) {
+		return &ConfigError{Err: fmt.Errorf("no tested Go packages found under %s", root)}
+	}
+
+	mutationDir, err := makeMutationDir()
+	if err != nil {
+		return err
+	}
+
+	for i, pkg := range pkgs {
+		if !pkg.Tested {
+			// helpers are only worth mutating if some tests use them
+			dependents, err := r.testedBy(pkg.Dir)
+			if err != nil {
+				return fmt.Errorf("%s: %w", pkg.Dir, err)
+			}
+			if len(dependents) == 0 {
+				continue
+			}
+		}
+
+		fmt.Printf("# %s\n", pkg.Dir)
+
+		// each package gets its own directory, mutants are numbered
+		// within their package
+		pkgMutationDir := filepath.Join(mutationDir, strconv.Itoa(i))
+		err := os.MkdirAll(pkgMutationDir, os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		err = r.testPackage(pkg.Files, pkgMutationDir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkg.Dir, err)
+		}
+	}
+
+	return r.finish()
+}
+
+// discover tests every package of the module of the working directory, as
+// selene run does without files, so a first run needs no arguments. Files
+// first runs are rarely meant to mutate, generated code and main.go files,
+// are skipped.
+func discover(opts options) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	root := nearestModule(wd)
+	if root == "" {
+		return &ConfigError{Err: fmt.Errorf("no go.mod found in %s or its parents, pass the files to mutate", wd)}
+	}
+
+	opts.discover = true
+	return runAll(opts, root)
+}
+
+// nearestModule returns the directory of the closest go.mod in dir or its
+// parents, or nothing if there is none.
+func nearestModule(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// findPackages walks root looking for go.mod files and returns the
+// packages of every module found, sorted by directory, and the directories
+// of tested packages outside of any module. Packages without tests are
+// returned too, as they may be test helpers. Directories that the go
+// command ignores (vendor, testdata, hidden ones) are skipped.
+func findPackages(root string) ([]goPackage, []string, error) {
+	var modules []string
+	pkgs := map[string]*goPackage{}
+	hasTests := map[string]bool{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+		if name == "go.mod" {
+			modules = append(modules, dir)
+			return nil
+		}
+
+		if filepath.Ext(name) != ".go" {
+			return nil
+		}
+
+		if strings.HasSuffix(name, "_test.go") {
+			hasTests[dir] = true
+			return nil
+		}
+
+		pkg, ok := pkgs[dir]
+		if !ok {
+			pkg = &goPackage{Dir: dir}
+			pkgs[dir] = pkg
+		}
+		pkg.Files = append(pkg.Files, path)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result []goPackage
+	var orphans []string
+	for dir, pkg := range pkgs {
+		pkg.Tested = hasTests[dir]
+		pkg.Module = enclosingModule(modules, dir)
+		if pkg.Module == "" {
+			if pkg.Tested {
+				orphans = append(orphans, dir)
+			}
+			continue
+		}
+
+		result = append(result, *pkg)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Dir < result[j].Dir
+	})
+	sort.Strings(orphans)
+
+	return result, orphans, nil
+}
+
+// enclosingModule returns the innermost module directory containing dir.
+func enclosingModule(modules []string, dir string) string {
+	var module string
+	for _, m := range modules {
+		if (dir == m || strings.HasPrefix(dir, m+string(filepath.Separator))) && len(m) > len(module) {
+			module = m
+		}
+	}
+	return module
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"log"
+)
+
+// sample returns about percent of the mutants, picked by hashing their IDs
+// with the seed, so the same seed picks the same mutants as long as the
+// code keeps them in place.
+func sample(mutants []mutant, percent float64, seed int64) []mutant {
+	var picked []mutant
+	for _, mt := range mutants {
+		h := fnv.New64a()
+		binary.Write(h, binary.LittleEndian, seed)
+		h.Write([]byte(mt.ID))
+		if float64(h.Sum64()%10000) < percent*100 {
+			picked = append(picked, mt)
+		}
+	}
+
+	log.Printf("sampled %d of %d mutants", len(picked), len(mutants))
+	return picked
+}
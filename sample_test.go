@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	var mutants []mutant
+	for i := 0; i < 1000; i++ {
+		mutants = append(mutants, mutant{ID: fmt.Sprintf("f.go:%d:1:Negation", i)})
+	}
+
+	tests := []struct {
+		percent  float64
+		min, max int
+	}{
+		{0, 0, 0},
+		{10, 50, 150},
+		{50, 400, 600},
+		{100, 1000, 1000},
+	}
+
+	for _, tt := range tests {
+		got := sample(mutants, tt.percent, 1)
+		if len(got) < tt.min || len(got) > tt.max {
+			t.Errorf("sample(%v%%) picked %d of %d, want %d to %d", tt.percent, len(got), len(mutants), tt.min, tt.max)
+		}
+	}
+
+	a, b := sample(mutants, 50, 1), sample(mutants, 50, 1)
+	if len(a) != len(b) {
+		t.Fatalf("the same seed picked %d and %d mutants", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			t.Fatalf("the same seed picked %s and %s", a[i].ID, b[i].ID)
+		}
+	}
+
+	c := sample(mutants, 50, 2)
+	same := len(a) == len(c)
+	for i := 0; same && i < len(a); i++ {
+		same = a[i].ID == c[i].ID
+	}
+	if same {
+		t.Error("seeds 1 and 2 picked the same mutants")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// Reasons for not mutating a file.
+const (
+	reasonConstraints = "not mutable: excluded by build constraints"
+	reasonAssembly    = "not mutable: linked assembly"
+	reasonNotImpacted = "not impacted by the diff"
+	reasonExcluded    = "all functions excluded by config"
+	reasonUnexported  = "no exported functions"
+	reasonGenerated   = "generated code"
+	reasonMain        = "main.go, skipped by default"
+)
+
+// scanFiles separates the files that can be mutated from the ones that
+// can't. Mutating a file the build ignores is pointless, and functions
+// implemented in assembly fail to build in confusing ways, so both are
+// reported as skipped instead.
+func scanFiles(ctx *build.Context, filenames []string) ([]string, []report.Skipped, error) {
+	var mutable []string
+	var skipped []report.Skipped
+	assembly := map[string]bool{}
+
+	for _, filename := range filenames {
+		dir, name := filepath.Split(filename)
+		if dir == "" {
+			dir = "."
+		}
+
+		match, err := ctx.MatchFile(dir, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !match {
+			skipped = append(skipped, report.Skipped{File: filename, Reason: reasonConstraints})
+			continue
+		}
+
+		hasAssembly, ok := assembly[dir]
+		if !ok {
+			matches, err := filepath.Glob(filepath.Join(dir, "*.s"))
+			if err != nil {
+				return nil, nil, err
+			}
+			hasAssembly = len(matches) > 0
+			assembly[dir] = hasAssembly
+		}
+
+		if hasAssembly {
+			linked, err := declaresAssembly(filename)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if linked {
+				skipped = append(skipped, report.Skipped{File: filename, Reason: reasonAssembly})
+				continue
+			}
+		}
+
+		mutable = append(mutable, filename)
+	}
+
+	return mutable, skipped, nil
+}
+
+// skipDefaults leaves out the files selene run skips without files given,
+// appending them to skipped: generated code, fixed by generating it again
+// rather than by tests, and main.go files, mostly wiring tests rarely run.
+func skipDefaults(filenames []string, skipped *[]report.Skipped) ([]string, error) {
+	var kept []string
+	for _, filename := range filenames {
+		if filepath.Base(filename) == "main.go" {
+			*skipped = append(*skipped, report.Skipped{File: filename, Reason: reasonMain})
+			continue
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if ast.IsGenerated(file) {
+			*skipped = append(*skipped, report.Skipped{File: filename, Reason: reasonGenerated})
+			continue
+		}
+
+		kept = append(kept, filename)
+	}
+	return kept, nil
+}
+
+// declaresAssembly reports whether the file declares functions without a
+// body, which are implemented in assembly.
+func declaresAssembly(filename string) (bool, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return false, err
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if ok && fn.Body == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// splitTargeted keeps the files with functions to mutate in targets and
+// appends the others to skipped with the given reason.
+func splitTargeted(filenames []string, targets funcSet, reason string, skipped *[]report.Skipped) ([]string, error) {
+	var kept []string
+	for _, filename := range filenames {
+		absPath, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(targets[absPath]) == 0 {
+			*skipped = append(*skipped, report.Skipped{File: filename, Reason: reason})
+			continue
+		}
+		kept = append(kept, filename)
+	}
+	return kept, nil
+}
+
+// excludeFuncs returns the functions of targets whose names don't match
+// any of patterns. A nil targets stands for all the functions declared in
+// filenames.
+func excludeFuncs(pkgPath string, filenames []string, targets funcSet, patterns []*regexp.Regexp) (funcSet, error) {
+	kept := funcSet{}
+	for _, filename := range filenames {
+		absPath, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+
+			line := fset.Position(fd.Name.Pos()).Line
+			if targets != nil && !targets[absPath][line] {
+				continue
+			}
+
+			name := funcName(fd)
+			if matchAny(patterns, name, pkgPath+"."+name) {
+				continue
+			}
+
+			kept.add(absPath, line)
+		}
+	}
+	return kept, nil
+}
+
+// funcName returns the name of a function as Func, or Type.Method for
+// methods.
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+
+	typ := fd.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+
+	// type parameters of generic receivers
+	switch x := typ.(type) {
+	case *ast.IndexExpr:
+		typ = x.X
+	case *ast.IndexListExpr:
+		typ = x.X
+	}
+
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
+
+func matchAny(patterns []*regexp.Regexp, names ...string) bool {
+	for _, p := range patterns {
+		for _, name := range names {
+			if p.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
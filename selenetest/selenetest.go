@@ -0,0 +1,150 @@
+// Package selenetest guards the mutation score of a package from its own
+// tests, so a plain go test enforces it without separate CI steps:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(selenetest.Run(m, selenetest.Options{Since: "main", MinScore: 80}))
+//	}
+//
+// Once the tests pass, Run runs the selene binary on the package and fails
+// if the mutation score is too low or if mutants killed in a baseline
+// report now survive. The tests selene runs on the mutants skip the guard.
+// The binary is not part of this package: install it with
+//
+//	go install github.com/danicat/selene@latest
+//
+// or set Options.Binary to its path.
+package selenetest
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// nested is set in the environment of selene, so the go test runs it
+// makes don't run it again.
+const nested = "SELENETEST_NESTED"
+
+// Options are the settings of the guard.
+type Options struct {
+	// Binary is the selene command, selene from PATH by default.
+	Binary string
+	// Since only tests and scores the mutants on lines changed since a
+	// git ref or within a time window, as with --since, which keeps the
+	// guard quick. Empty tests every mutant.
+	Since string
+	// History is the file recording which tests kill mutants, to run
+	// them first, as with --history.
+	History string
+	// MinScore is the mutation score in percent below which the guard
+	// fails, 0 for none.
+	MinScore float64
+	// Baseline is the JSON report of an earlier run. Mutants it has
+	// killed that survive now fail the guard, as with selene compare.
+	Baseline string
+	// Args are other flags passed to selene, as in --mode quick.
+	Args []string
+}
+
+// Run runs the tests of m and then, if they pass, the mutation score
+// guard of the package, and returns the exit code of both. The guard is
+// skipped with -short, and in the go test runs selene makes.
+func Run(m *testing.M, opts Options) int {
+	code := m.Run()
+	if code != 0 || testing.Short() || os.Getenv(nested) != "" {
+		return code
+	}
+
+	err := guard(".", opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--- FAIL: mutation score guard\n    %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// guard runs selene on the package in dir and checks its report against
+// opts.
+func guard(dir string, opts Options) error {
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return err
+	}
+
+	binary := opts.Binary
+	if binary == "" {
+		binary = "selene"
+	}
+	binary, err = exec.LookPath(binary)
+	if err != nil {
+		return fmt.Errorf("selene not found, install it with go install github.com/danicat/selene@latest or set Options.Binary: %s", err)
+	}
+	// run from dir, which relative paths would be resolved against
+	binary, err = filepath.Abs(binary)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "selenetest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	reportFile := filepath.Join(tmp, "report.json")
+
+	args := []string{"run", "--report", "json=" + reportFile}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.History != "" {
+		args = append(args, "--history", opts.History)
+	}
+	args = append(args, opts.Args...)
+	args = append(args, append(pkg.GoFiles, pkg.CgoFiles...)...)
+
+	// survivors make selene fail, the report tells whether they matter
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), nested+"=1")
+	out, runErr := cmd.CombinedOutput()
+
+	doc, err := report.ReadDocument(reportFile)
+	if err != nil {
+		if runErr != nil {
+			return fmt.Errorf("selene failed: %s\n%s", runErr, out)
+		}
+		return err
+	}
+
+	killed, total := 0, 0
+	for _, r := range doc.Results {
+		killed += r.Count(report.Killed)
+		total += r.Count(report.Killed) + r.Count(report.Survived)
+	}
+	if score := 100 * float64(killed) / float64(max(total, 1)); total > 0 && score < opts.MinScore {
+		return fmt.Errorf("mutation score %.1f%%, below %g%%, %d of %d mutants survived\n%s", score, opts.MinScore, total-killed, total, out)
+	}
+
+	if opts.Baseline != "" {
+		baseline, err := report.ReadDocument(opts.Baseline)
+		if err != nil {
+			return err
+		}
+
+		c := report.Compare(baseline, doc)
+		if len(c.NewlySurviving) > 0 {
+			msg := fmt.Sprintf("%d mutants newly survive:", len(c.NewlySurviving))
+			for _, change := range c.NewlySurviving {
+				msg += "\n    " + change.ID
+			}
+			return fmt.Errorf("%s", msg)
+		}
+	}
+
+	return nil
+}
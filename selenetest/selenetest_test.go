@@ -0,0 +1,144 @@
+package selenetest
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// binary is the selene command built for the tests, empty with -short.
+var binary string
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	flag.Parse()
+	if testing.Short() {
+		return m.Run()
+	}
+
+	dir, err := os.MkdirTemp("", "selenetest")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	binary = filepath.Join(dir, "selene")
+	out, err := exec.Command("go", "build", "-o", binary, "github.com/danicat/selene").CombinedOutput()
+	if err != nil {
+		panic(string(out))
+	}
+	return m.Run()
+}
+
+// pkgDir writes a package whose tests kill some of its mutants and let
+// others survive, and returns its directory.
+func pkgDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example\n\ngo 1.21\n",
+		"cond.go": `package example
+
+import "fmt"
+
+func cond(x int) error {
+	if x > 0 {
+		return fmt.Errorf("this should never happen")
+	}
+	return nil
+}
+`,
+		"cond_test.go": `package example
+
+import "testing"
+
+func TestCond(t *testing.T) {
+	if err := cond(-1); err != nil {
+		t.Fatal(err)
+	}
+}
+`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGuardMinScore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs selene")
+	}
+	dir := pkgDir(t)
+
+	// selene fails with survivors, the guard only with a low score
+	if err := guard(dir, Options{Binary: binary}); err != nil {
+		t.Errorf("guard() without a minimum score = %v, want nil", err)
+	}
+
+	err := guard(dir, Options{Binary: binary, MinScore: 100})
+	if err == nil || !strings.Contains(err.Error(), "below 100%") {
+		t.Errorf("guard() with a minimum score of 100%% = %v, want a score below it", err)
+	}
+}
+
+func TestGuardBaseline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs selene")
+	}
+	dir := pkgDir(t)
+
+	// the baseline saw the survivors killed
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	if err := guard(dir, Options{Binary: binary, Args: []string{"--report", "json=" + baseline}}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := report.ReadDocument(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var survivors int
+	for i := range doc.Results {
+		for j := range doc.Results[i].Mutants {
+			if m := &doc.Results[i].Mutants[j]; m.Status == report.Survived {
+				m.Status = report.Killed
+				survivors++
+			}
+		}
+	}
+	if survivors == 0 {
+		t.Fatal("no survivors to compare")
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(baseline, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = guard(dir, Options{Binary: binary, Baseline: baseline})
+	if err == nil || !strings.Contains(err.Error(), "newly survive") {
+		t.Errorf("guard() against a baseline killing the survivors = %v, want newly surviving mutants", err)
+	}
+}
+
+func TestGuardMissingBinary(t *testing.T) {
+	err := guard(pkgDir(t), Options{Binary: filepath.Join(t.TempDir(), "selene")})
+	if err == nil || !strings.Contains(err.Error(), "Options.Binary") {
+		t.Errorf("guard() without selene = %v, want an error telling how to install it", err)
+	}
+}
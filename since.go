@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// windowDays matches the time windows counted in days or weeks, such as
+// 14d or 2w.
+var windowDays = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// parseWindow parses a time window: a go duration, or a number of days or
+// weeks.
+func parseWindow(s string) (time.Duration, error) {
+	if m := windowDays.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, err
+		}
+		d := time.Duration(n) * 24 * time.Hour
+		if m[2] == "w" {
+			d *= 7
+		}
+		return d, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// blameArgs returns the git blame arguments making the lines older than
+// since boundary lines. since is a time window or a git ref.
+func blameArgs(since string, now time.Time) []string {
+	if d, err := parseWindow(since); err == nil {
+		return []string{"--since=" + now.Add(-d).Format(time.RFC3339)}
+	}
+	return []string{since + ".."}
+}
+
+// newLines returns the lines of filename added or modified since a ref or
+// within a time window, according to git blame. Uncommitted changes are
+// new, and so are untracked files: all reports they are new as a whole.
+func newLines(filename, since string) (map[int]bool, bool, error) {
+	dir := filepath.Dir(filename)
+
+	err := exec.Command("git", "-C", dir, "ls-files", "--error-unmatch", filepath.Base(filename)).Run()
+	if err != nil {
+		if exec.Command("git", "-C", dir, "rev-parse", "--git-dir").Run() != nil {
+			return nil, false, fmt.Errorf("--since needs a git repository: %s", dir)
+		}
+		return nil, true, nil
+	}
+
+	// root commits are only boundaries when they are out of the window
+	args := append([]string{"-C", dir, "blame", "--porcelain", "--root"}, blameArgs(since, time.Now())...)
+	args = append(args, "--", filepath.Base(filename))
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("git blame %s failed: %s", filename, err)
+	}
+
+	// each line starts with a header, sha orig-line final-line [count],
+	// followed by the details of the commit the first time it shows up,
+	// among which boundary for commits before the window
+	boundary := map[string]bool{}
+	var headers []struct {
+		sha  string
+		line int
+	}
+	expectHeader := true
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// the content of the line ends its entry
+			expectHeader = true
+		case expectHeader:
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, false, fmt.Errorf("git blame %s: unexpected line %q", filename, line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, false, fmt.Errorf("git blame %s: unexpected line %q", filename, line)
+			}
+			headers = append(headers, struct {
+				sha  string
+				line int
+			}{fields[0], n})
+			expectHeader = false
+		case line == "boundary":
+			boundary[headers[len(headers)-1].sha] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+
+	lines := map[int]bool{}
+	for _, h := range headers {
+		if !boundary[h.sha] {
+			lines[h.line] = true
+		}
+	}
+	return lines, false, nil
+}
+
+// sinceFilter returns the mutants on lines added or modified since a ref
+// or within a time window.
+func sinceFilter(mutants []mutant, since string) ([]mutant, error) {
+	type fileLines struct {
+		lines map[int]bool
+		all   bool
+	}
+	files := map[string]fileLines{}
+
+	var selected []mutant
+	for _, mt := range mutants {
+		fl, ok := files[mt.File]
+		if !ok {
+			lines, all, err := newLines(mt.File, since)
+			if err != nil {
+				return nil, err
+			}
+			fl = fileLines{lines: lines, all: all}
+			files[mt.File] = fl
+		}
+
+		if fl.all || fl.lines[mt.Pos.Line] {
+			selected = append(selected, mt)
+		}
+	}
+	return selected, nil
+}
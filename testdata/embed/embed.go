@@ -0,0 +1,16 @@
+package embed
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed greeting.txt
+var greeting string
+
+func greet(name string) string {
+	if name == "" {
+		return strings.TrimSpace(greeting)
+	}
+	return strings.TrimSpace(greeting) + ", " + name
+}
@@ -0,0 +1,12 @@
+package embed
+
+import "testing"
+
+func TestGreet(t *testing.T) {
+	if got := greet(""); got != "hello" {
+		t.Errorf("greet(\"\") = %q, want hello", got)
+	}
+	if got := greet("selene"); got != "hello, selene" {
+		t.Errorf("greet(\"selene\") = %q, want hello, selene", got)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// defaultScope is the thresholds key applying to packages no other
+// pattern matches.
+const defaultScope = "default"
+
+// thresholds are the minimum mutation scores, in percent, of groups of
+// packages, by pattern matched against their directory relative to the
+// current one. Patterns are those of path.Match, optionally ending with
+// /** to match subdirectories too.
+type thresholds map[string]float64
+
+// checkThresholds validates the thresholds of the config.
+func checkThresholds(t thresholds) error {
+	for pattern, min := range t {
+		if min < 0 || min > 100 {
+			return &ConfigError{Err: fmt.Errorf("invalid threshold %v for %s, expected a percentage", min, pattern)}
+		}
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return &ConfigError{Err: fmt.Errorf("invalid thresholds pattern %s: %s", pattern, err)}
+		}
+	}
+	return nil
+}
+
+// scope returns the pattern governing the package in dir, the longest
+// one matching it, and its threshold. Packages no pattern matches fall
+// in the default scope, which unless configured lets no mutant survive.
+func (t thresholds) scope(dir string) (string, float64) {
+	best := ""
+	for pattern := range t {
+		if pattern != defaultScope && matchScope(pattern, dir) && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best != "" {
+		return best, t[best]
+	}
+
+	if min, ok := t[defaultScope]; ok {
+		return defaultScope, min
+	}
+	return defaultScope, 100
+}
+
+// matchScope reports whether pattern matches dir, a slash separated path.
+func matchScope(pattern, dir string) bool {
+	if pattern == "**" {
+		return true
+	}
+
+	prefix, recursive := strings.CutSuffix(pattern, "/**")
+	if !recursive {
+		ok, _ := path.Match(pattern, dir)
+		return ok
+	}
+
+	// the prefix matches as many leading elements of dir as it has
+	n := strings.Count(prefix, "/") + 1
+	elems := strings.Split(dir, "/")
+	if len(elems) < n {
+		return false
+	}
+	ok, _ := path.Match(prefix, strings.Join(elems[:n], "/"))
+	return ok
+}
+
+// scopeScore is the mutation score of the packages in a scope.
+type scopeScore struct {
+	Scope     string
+	Threshold float64
+	Killed    int
+	Total     int // killed and survived
+}
+
+func (s scopeScore) score() float64 {
+	return float64(s.Killed) / float64(s.Total) * 100
+}
+
+// failedScopes returns the scopes whose score is below their threshold,
+// sorted by pattern. Scopes without mutants can't fail.
+func failedScopes(scores map[string]*scopeScore) []scopeScore {
+	var failed []scopeScore
+	for _, s := range scores {
+		if s.Total > 0 && s.score() < s.Threshold {
+			failed = append(failed, *s)
+		}
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].Scope < failed[j].Scope
+	})
+	return failed
+}
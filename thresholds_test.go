@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestMatchScope(t *testing.T) {
+	tests := []struct {
+		pattern, dir string
+		want         bool
+	}{
+		{"**", "any/dir", true},
+		{"internal/report", "internal/report", true},
+		{"internal/report", "internal/report/sub", false},
+		{"internal/*", "internal/report", true},
+		{"internal/*", "internal/report/sub", false},
+		{"internal/**", "internal/report/sub", true},
+		{"internal/**", "internal", true},
+		{"internal/**", "cmd/internal", false},
+		{"*/api/**", "svc/api/v1", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchScope(tt.pattern, tt.dir); got != tt.want {
+			t.Errorf("matchScope(%q, %q) = %v, want %v", tt.pattern, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestThresholdsScope(t *testing.T) {
+	th := thresholds{defaultScope: 60, "internal/**": 80, "internal/report": 90}
+	tests := []struct {
+		dir   string
+		scope string
+		min   float64
+	}{
+		{"internal/report", "internal/report", 90},
+		{"internal/mutator", "internal/**", 80},
+		{"cmd", defaultScope, 60},
+	}
+
+	for _, tt := range tests {
+		scope, min := th.scope(tt.dir)
+		if scope != tt.scope || min != tt.min {
+			t.Errorf("scope(%q) = %q, %v, want %q, %v", tt.dir, scope, min, tt.scope, tt.min)
+		}
+	}
+
+	if scope, min := (thresholds{}).scope("cmd"); scope != defaultScope || min != 100 {
+		t.Errorf("scope without thresholds = %q, %v, want %q, 100", scope, min, defaultScope)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// toolchain is the go command and target platform used to build and test
+// the mutants, by default the go binary in PATH for the host platform.
+type toolchain struct {
+	goBin  string
+	goos   string
+	goarch string
+	exec   string   // program running test binaries, as with go test -exec
+	tags   []string // build tags of the tests, as with go test -tags
+	docker string   // image running the go commands, if any
+
+	// offline keeps the go commands from downloading modules, once the
+	// baseline has downloaded those the tests need
+	offline bool
+
+	adapter     *testAdapter // wrapper running the tests, if any
+	environment *environment // host variables passed on, all if nil
+	home        string       // HOME and TMPDIR of the go command, if isolated
+}
+
+// command returns a go command running in dir for the target platform.
+func (tc toolchain) command(dir string, args ...string) *exec.Cmd {
+	if tc.docker != "" {
+		run := tc.dockerArgs(dir, args...)
+		return exec.Command(run[0], run[1:]...)
+	}
+
+	cmd := exec.Command(tc.goBin, args...)
+	cmd.Dir = dir
+	cmd.Env = tc.environ(dir)
+
+	return cmd
+}
+
+// environ returns the environment of the commands running in dir.
+func (tc toolchain) environ(dir string) []string {
+	env := append(tc.hostEnv(), tc.env()...)
+	env = append(env, tc.moduleEnv(dir)...)
+	return append(env, tc.isolatedEnv()...)
+}
+
+// env returns the environment selecting the target platform.
+func (tc toolchain) env() []string {
+	var env []string
+	if tc.goos != "" {
+		env = append(env, "GOOS="+tc.goos)
+	}
+	if tc.goarch != "" {
+		env = append(env, "GOARCH="+tc.goarch)
+	}
+	return env
+}
+
+// moduleEnv returns the environment keeping the go commands in dir from
+// changing go.mod and go.sum, so mutants can't either: -mod=readonly,
+// unless the module vendors its dependencies, and no module downloads
+// once offline.
+func (tc toolchain) moduleEnv(dir string) []string {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	dir, _ = filepath.Abs(dir)
+	_, err := os.Stat(filepath.Join(moduleRoot(dir), "vendor", "modules.txt"))
+
+	var env []string
+	if flags := readonlyFlags(os.Getenv("GOFLAGS"), err == nil); flags != "" {
+		env = append(env, "GOFLAGS="+flags)
+	}
+	if tc.offline {
+		env = append(env, "GOPROXY=off")
+	}
+	return env
+}
+
+// readonlyFlags returns goflags with its -mod flag replaced by
+// -mod=readonly. -mod=vendor is kept, as is the default of vendored
+// modules, which is vendor too.
+func readonlyFlags(goflags string, vendored bool) string {
+	mod := "-mod=readonly"
+	if vendored {
+		mod = ""
+	}
+
+	var flags []string
+	for _, f := range strings.Fields(goflags) {
+		value, ok := strings.CutPrefix(strings.TrimLeft(f, "-"), "mod=")
+		if !ok {
+			flags = append(flags, f)
+			continue
+		}
+		if value == "vendor" {
+			mod = f
+		}
+	}
+	if mod != "" {
+		flags = append(flags, mod)
+	}
+	return strings.Join(flags, " ")
+}
+
+// moduleChanges are the errors of go commands needing to change go.mod or
+// go.sum, or to download modules once offline.
+var moduleChanges = []string{
+	"updates to go.mod needed",
+	"missing go.sum entry",
+	"no required module provides package",
+	"import lookup disabled",
+	"inconsistent vendoring",
+	"GOPROXY=off",
+}
+
+// moduleError returns an error explaining how to fix the module if the
+// output of a failed go command shows it needs changes, and nil otherwise.
+func moduleError(out []byte) error {
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		// with -json, build errors are the output of events
+		var event TestEvent
+		if json.Unmarshal(line, &event) == nil {
+			line = []byte(event.Output)
+		}
+
+		for _, msg := range moduleChanges {
+			if bytes.Contains(line, []byte(msg)) {
+				return &ConfigError{Err: fmt.Errorf("%s\nthe module needs changes to go.mod or go.sum, which selene never makes: run go mod tidy, or go mod vendor if it vendors its dependencies, and try again", bytes.TrimSpace(line))}
+			}
+		}
+	}
+	return nil
+}
+
+// buildContext returns the context matching files for the target platform.
+func (tc toolchain) buildContext() *build.Context {
+	ctx := build.Default
+	if tc.goos != "" {
+		ctx.GOOS = tc.goos
+	}
+	if tc.goarch != "" {
+		ctx.GOARCH = tc.goarch
+	}
+	ctx.BuildTags = tc.tags
+	return &ctx
+}
+
+// testArgs returns the go test arguments for the overlay and flags.
+func (tc toolchain) testArgs(overlay string, testFlags []string) []string {
+	args := []string{"test"}
+	if overlay != "" {
+		args = append(args, "-overlay="+overlay)
+	}
+	if tc.exec != "" {
+		args = append(args, "-exec", tc.exec)
+	}
+	args = append(args, testFlags...)
+	return append(args, ".")
+}
+
+func (tc toolchain) runGoTest(pkgDir, overlay, logFile string, testFlags []string) ([]TestEvent, error) {
+	tests, _, err := tc.measureGoTest(pkgDir, overlay, logFile, testFlags)
+	return tests, err
+}
+
+// testUsage is what a go test run took: how long, how much CPU time, and the
+// memory of its largest process, 0 if unknown.
+type testUsage struct {
+	wall, cpu time.Duration
+	maxRSS    int64 // bytes
+}
+
+// measureGoTest runs go test like runGoTest, and measures its usage.
+func (tc toolchain) measureGoTest(pkgDir, overlay, logFile string, testFlags []string) ([]TestEvent, testUsage, error) {
+	tc, cleanup, err := tc.isolate()
+	if err != nil {
+		return nil, testUsage{}, err
+	}
+	defer cleanup()
+
+	if tc.adapter != nil {
+		return tc.runAdapter(pkgDir, overlay, logFile, testFlags)
+	}
+
+	args := append([]string{"-json"}, testFlags...)
+
+	// run from the package directory so its module (and toolchain)
+	// is the one being used, even for nested modules
+	cmd := tc.command(pkgDir, tc.testArgs(overlay, args)...)
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if modErr := moduleError(out); modErr != nil {
+			return nil, testUsage{}, modErr
+		}
+		// go test returns with exit code 1 if tests fail
+		// let's log just in case but move on
+		log.Println(err)
+	}
+	u := measure(cmd.ProcessState, time.Since(start))
+
+	log.Printf("go test log: %s", logFile)
+
+	err = writeGoTestLog(logFile, out)
+	if err != nil {
+		return nil, u, fmt.Errorf("failed to write go test log: %s", err)
+	}
+
+	tests, err := parseGoTestOutput(out)
+	return tests, u, err
+}
+
+// warmCache builds the packages of a go test run in dir, with its overlay
+// and flags, and their tests, without running any, so the run is timed
+// with a warm build cache: the worker count and the mutant timeouts are
+// derived from it. Errors are only logged, as the run reports them.
+func (tc toolchain) warmCache(dir, overlay string, testFlags, packages []string) {
+	// wrappers may not take the flags selecting no test
+	if tc.adapter != nil {
+		return
+	}
+
+	tc, cleanup, err := tc.isolate()
+	if err != nil {
+		log.Printf("failed to warm the build cache: %s", err)
+		return
+	}
+	defer cleanup()
+
+	args := []string{"test"}
+	if overlay != "" {
+		args = append(args, "-overlay="+overlay)
+	}
+	if tc.exec != "" {
+		args = append(args, "-exec", tc.exec)
+	}
+	args = append(args, testFlags...)
+	// the last -run wins
+	args = append(args, "-run", "^$", "-count=1")
+	args = append(args, packages...)
+
+	log.Printf("warming the build cache: go %s", strings.Join(args, " "))
+	out, err := tc.command(dir, args...).CombinedOutput()
+	if err != nil {
+		log.Printf("failed to warm the build cache: %s\n%s", err, out)
+	}
+}
+
+// measure returns the usage of a finished process.
+func measure(state *os.ProcessState, wall time.Duration) testUsage {
+	if state == nil {
+		return testUsage{wall: wall}
+	}
+	return testUsage{wall: wall, cpu: state.UserTime() + state.SystemTime(), maxRSS: maxRSS(state)}
+}
+
+// goVersion returns the version of the go toolchain used for pkgDir, after
+// GOTOOLCHAIN and the toolchain directive of its module are applied.
+func (tc toolchain) goVersion(pkgDir string) (string, error) {
+	out, err := tc.command(pkgDir, "env", "GOVERSION").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get go version: %s", err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// importPath returns the import path of the package in dir.
+func (tc toolchain) importPath(dir string) (string, error) {
+	out, err := tc.command(dir, "list", "-f", "{{.ImportPath}}", ".").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get import path of %s: %s", dir, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// selectTests returns the test flags running only the tests matching a
+// -run pattern. Ginkgo specs all run within a single go test, so they are
+// selected with their own focus instead.
+func (tc toolchain) selectTests(pattern string) []string {
+	if tc.adapter != nil && tc.adapter.Format == "ginkgo" {
+		return []string{"-ginkgo.focus", pattern}
+	}
+	return []string{"-run", pattern}
+}
+
+// runAdapter runs the tests through the configured wrapper, keeping its
+// report, if any, next to the log.
+func (tc toolchain) runAdapter(pkgDir, overlay, logFile string, testFlags []string) ([]TestEvent, testUsage, error) {
+	args := tc.testArgs(overlay, testFlags)[1:]
+	out, tests, u, err := tc.adapter.run(tc, pkgDir, args, adapterReport(logFile))
+	if err != nil {
+		return nil, u, err
+	}
+
+	log.Printf("go test log: %s", logFile)
+
+	err = writeGoTestLog(logFile, out)
+	if err != nil {
+		return nil, u, fmt.Errorf("failed to write go test log: %s", err)
+	}
+
+	return tests, u, nil
+}
+
+// adapterReport returns the path of the report of a test wrapper, based
+// on the path of the log of the run.
+func adapterReport(logFile string) string {
+	return strings.TrimSuffix(logFile, ".log.gz") + ".report"
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestReadonlyFlags(t *testing.T) {
+	tests := []struct {
+		goflags  string
+		vendored bool
+		want     string
+	}{
+		{"", false, "-mod=readonly"},
+		{"", true, ""},
+		{"-mod=mod", false, "-mod=readonly"},
+		{"-trimpath -mod=mod", false, "-trimpath -mod=readonly"},
+		{"-mod=vendor", false, "-mod=vendor"},
+		{"--mod=vendor -v", true, "-v --mod=vendor"},
+		{"-mod=mod", true, ""},
+	}
+
+	for _, tt := range tests {
+		if got := readonlyFlags(tt.goflags, tt.vendored); got != tt.want {
+			t.Errorf("readonlyFlags(%q, %v) = %q, want %q", tt.goflags, tt.vendored, got, tt.want)
+		}
+	}
+}
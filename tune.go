@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/danicat/selene/internal/report"
+)
+
+// tune reads the JSON reports of several runs and recommends which
+// mutators to disable, and where tests are missing, from how their
+// mutants fared over time.
+func tune(args []string) error {
+	fs := flag.NewFlagSet("report tune", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	err := fs.Parse(args)
+	if err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	if *format != "text" && *format != "json" {
+		return &ConfigError{Err: fmt.Errorf("unknown format %q, expected text or json", *format)}
+	}
+	if fs.NArg() < 2 {
+		return &ConfigError{Err: fmt.Errorf("usage: selene report tune [-format text|json] <report.json>..., with the reports of at least two runs")}
+	}
+
+	var docs []report.Document
+	for _, filename := range fs.Args() {
+		doc, err := report.ReadDocument(filename)
+		if err != nil {
+			return &ConfigError{Err: err}
+		}
+		docs = append(docs, doc)
+	}
+
+	t := report.Tune(docs)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t)
+	}
+	return writeTuning(os.Stdout, t)
+}
+
+func writeTuning(w io.Writer, t report.Tuning) error {
+	fmt.Fprintf(w, "mutators over %d runs, as of the last one:\n", t.Runs)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "    mutator\tmutants\tkilled\tsurvived\tbuild failed\tsurviving every run\t")
+	for _, s := range t.Mutators {
+		fmt.Fprintf(tw, "    %s\t%d\t%d\t%d\t%d\t%d\t\n", s.Mutator, s.Mutants, s.Killed, s.Survived, s.BuildFailed, s.Chronic)
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "recommendations: %d\n", len(t.Recommendations))
+	for _, r := range t.Recommendations {
+		fmt.Fprintf(w, "    %s\n", r)
+	}
+	return nil
+}
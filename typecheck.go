@@ -0,0 +1,399 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/danicat/selene/internal/mutator"
+)
+
+// needsTypes reports whether any of the mutators needs type information.
+func needsTypes(mutators []mutator.Mutator) bool {
+	for _, m := range mutators {
+		if m.Types {
+			return true
+		}
+	}
+	return false
+}
+
+// siblingFiles returns the other source files of the package of filename,
+// the ones type checking it depends on.
+func siblingFiles(ctx *build.Context, filename string) ([]string, error) {
+	bpkg, err := ctx.ImportDir(filepath.Dir(filename), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var siblings []string
+	for _, name := range append(bpkg.GoFiles, bpkg.CgoFiles...) {
+		path := filepath.Join(bpkg.Dir, name)
+		if path != filename {
+			siblings = append(siblings, path)
+		}
+	}
+	return siblings, nil
+}
+
+// typeInfo type checks the package of filename, with file standing for its
+// parse, and returns the types of its expressions and identifiers, and the
+// parses of the other files of the package, which the info refers to. Type
+// errors, such as dependencies that can't be imported, only leave the info
+// incomplete; the package still gets mutated as far as it is understood.
+func typeInfo(ctx *build.Context, fset *token.FileSet, filename string, file *ast.File) (*types.Info, []*ast.File) {
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+
+	files := []*ast.File{file}
+	siblings, err := siblingFiles(ctx, filename)
+	if err != nil {
+		log.Printf("type checking %s: %s", filename, err)
+	}
+	// with comments, as files changed by linked edits are written back
+	for _, sibling := range siblings {
+		f, err := parser.ParseFile(fset, sibling, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			log.Printf("type checking %s: %s", filename, err)
+			continue
+		}
+		files = append(files, f)
+	}
+
+	var typeErr error
+	conf := types.Config{
+		Importer:    sharedImporter(ctx),
+		FakeImportC: true,
+		Error: func(err error) {
+			if typeErr == nil {
+				typeErr = err
+			}
+		},
+	}
+	conf.Check(file.Name.Name, fset, files, info)
+	if typeErr != nil {
+		warnTypeError(filepath.Dir(filename), typeErr)
+	}
+
+	return info, files[1:]
+}
+
+// typeWarnings are the package directories whose type errors were already
+// reported.
+var typeWarnings sync.Map
+
+// warnTypeError reports, once per package, a type error of the package in
+// dir, as typed mutators skip what it leaves unknown.
+func warnTypeError(dir string, err error) {
+	if _, warned := typeWarnings.LoadOrStore(dir, true); warned {
+		log.Printf("type checking %s: %s", dir, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "selene: warning: type checking %s: %s; typed mutators skip what it leaves unknown\n", dir, err)
+}
+
+// checkedPackage is a package parsed and type checked for scanning, shared
+// by the scans of its files, which only read it.
+type checkedPackage struct {
+	once  sync.Once
+	key   string
+	fset  *token.FileSet
+	files map[string]*ast.File // by path
+	info  *types.Info
+	err   error
+}
+
+// maxCheckedPackages is how many packages checkedPackages keeps, enough
+// for the packages scanned at once.
+const maxCheckedPackages = 8
+
+// checkedPackages are the packages last checked, oldest first.
+var checkedPackages struct {
+	sync.Mutex
+	packages []*checkedPackage
+}
+
+// checkPackage returns the parse of filename and the type info of its
+// package, reusing those of an earlier call while the files of the package
+// keep the same content. They must not be changed.
+func checkPackage(ctx *build.Context, filename string) (*checkedPackage, error) {
+	key, err := packageKey(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	checkedPackages.Lock()
+	i := slices.IndexFunc(checkedPackages.packages, func(p *checkedPackage) bool { return p.key == key })
+	var p *checkedPackage
+	if i >= 0 {
+		p = checkedPackages.packages[i]
+	} else {
+		p = &checkedPackage{key: key}
+		checkedPackages.packages = append(checkedPackages.packages, p)
+		if len(checkedPackages.packages) > maxCheckedPackages {
+			checkedPackages.packages = checkedPackages.packages[1:]
+		}
+	}
+	checkedPackages.Unlock()
+
+	p.once.Do(func() {
+		p.fset = token.NewFileSet()
+		file, err := parser.ParseFile(p.fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			p.err = err
+			return
+		}
+
+		info, siblings := typeInfo(ctx, p.fset, filename, file)
+		p.info = info
+		p.files = map[string]*ast.File{filename: file}
+		for _, f := range siblings {
+			p.files[p.fset.File(f.Pos()).Name()] = f
+		}
+	})
+	return p, p.err
+}
+
+// packageKey identifies the content of the package of filename: the names
+// and content of its files, for the target platform and tags.
+func packageKey(ctx *build.Context, filename string) (string, error) {
+	siblings, err := siblingFiles(ctx, filename)
+	if err != nil {
+		return "", err
+	}
+	names := append(siblings, filename)
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%s %v\x00", ctx.GOOS, ctx.GOARCH, ctx.BuildTags)
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sourceImporter imports packages for type checking as the go command
+// finds them for the target platform, module aware: the standard library
+// from export data, and the other packages, of the module or its
+// dependencies, from source. Each package is checked once, without its
+// function bodies, and kept for the rest of the run, as selene never
+// changes them. It is safe for concurrent use.
+type sourceImporter struct {
+	mu       sync.Mutex
+	ctx      *build.Context
+	fset     *token.FileSet
+	std      types.Importer
+	packages map[string]*types.Package // by directory, nil while checked
+}
+
+// importers are the source importers by target platform and tags, shared
+// by every type check of the run, which must all see the same packages.
+var importers struct {
+	sync.Mutex
+	byContext map[string]*sourceImporter
+}
+
+// sharedImporter returns the source importer of the run for ctx.
+func sharedImporter(ctx *build.Context) *sourceImporter {
+	key := fmt.Sprintf("%s/%s %v", ctx.GOOS, ctx.GOARCH, ctx.BuildTags)
+
+	importers.Lock()
+	defer importers.Unlock()
+	if imp, ok := importers.byContext[key]; ok {
+		return imp
+	}
+
+	fset := token.NewFileSet()
+	imp := &sourceImporter{
+		ctx:      ctx,
+		fset:     fset,
+		std:      importer.ForCompiler(fset, "gc", nil),
+		packages: map[string]*types.Package{},
+	}
+	if importers.byContext == nil {
+		importers.byContext = map[string]*sourceImporter{}
+	}
+	importers.byContext[key] = imp
+	return imp
+}
+
+func (imp *sourceImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, "", 0)
+}
+
+// ImportFrom imports the package path as imported from the package in dir,
+// which decides the module and vendor directory it is found in.
+func (imp *sourceImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	imp.mu.Lock()
+	defer imp.mu.Unlock()
+	return imp.importFrom(path, dir, mode)
+}
+
+// lockedImporter imports the dependencies of the packages the importer
+// checks, while it holds its lock.
+type lockedImporter struct{ imp *sourceImporter }
+
+func (l lockedImporter) Import(path string) (*types.Package, error) {
+	return l.imp.importFrom(path, "", 0)
+}
+
+func (l lockedImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	return l.imp.importFrom(path, dir, mode)
+}
+
+func (imp *sourceImporter) importFrom(path, dir string, _ types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	bpkg, err := imp.ctx.Import(path, dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	if bpkg.Goroot {
+		return imp.std.Import(bpkg.ImportPath)
+	}
+
+	if pkg, ok := imp.packages[bpkg.Dir]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		return pkg, nil
+	}
+	imp.packages[bpkg.Dir] = nil
+
+	var files []*ast.File
+	for _, name := range append(bpkg.GoFiles, bpkg.CgoFiles...) {
+		f, err := parser.ParseFile(imp.fset, filepath.Join(bpkg.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			delete(imp.packages, bpkg.Dir)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	// errors of dependencies only leave the types they declare incomplete
+	conf := types.Config{
+		Importer:         lockedImporter{imp},
+		FakeImportC:      true,
+		IgnoreFuncBodies: true,
+		Error:            func(error) {},
+	}
+	pkg, _ := conf.Check(bpkg.ImportPath, imp.fset, files, nil)
+	imp.packages[bpkg.Dir] = pkg
+	return pkg, nil
+}
+
+// packageContent returns the content of the other source files of the
+// package of filename, which typed mutants depend on as well.
+func packageContent(ctx *build.Context, filename string) ([]byte, error) {
+	siblings, err := siblingFiles(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	for _, sibling := range siblings {
+		b, err := os.ReadFile(sibling)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, sibling...)
+		content = append(content, 0)
+		content = append(content, b...)
+	}
+	return content, nil
+}
+
+// importedKeys are the keys of the packages imported by each package,
+// by directory and target platform, found once per run, as the source
+// importer checks them once as well.
+var importedKeys struct {
+	sync.Mutex
+	byDir map[string]string
+}
+
+// importedKey identifies the content of the packages the package of
+// filename imports, directly or not, other than those of the standard
+// library, found as the source importer finds them. The types of typed
+// mutants depend on them as well.
+func importedKey(ctx *build.Context, filename string) (string, error) {
+	dir := filepath.Dir(filename)
+	memo := fmt.Sprintf("%s/%s %v\x00%s", ctx.GOOS, ctx.GOARCH, ctx.BuildTags, dir)
+
+	importedKeys.Lock()
+	defer importedKeys.Unlock()
+	if key, ok := importedKeys.byDir[memo]; ok {
+		return key, nil
+	}
+
+	bpkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return "", err
+	}
+
+	type pending struct{ path, dir string }
+	var queue []pending
+	for _, path := range bpkg.Imports {
+		queue = append(queue, pending{path, bpkg.Dir})
+	}
+
+	h := sha256.New()
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		imp := queue[0]
+		queue = queue[1:]
+		if imp.path == "unsafe" || imp.path == "C" {
+			continue
+		}
+
+		dep, err := ctx.Import(imp.path, imp.dir, 0)
+		if err != nil {
+			return "", err
+		}
+		if dep.Goroot || seen[dep.Dir] {
+			continue
+		}
+		seen[dep.Dir] = true
+
+		for _, name := range append(dep.GoFiles, dep.CgoFiles...) {
+			path := filepath.Join(dep.Dir, name)
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", path, len(b))
+			h.Write(b)
+		}
+		for _, path := range dep.Imports {
+			queue = append(queue, pending{path, dep.Dir})
+		}
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	if importedKeys.byDir == nil {
+		importedKeys.byDir = map[string]string{}
+	}
+	importedKeys.byDir[memo] = key
+	return key, nil
+}